@@ -0,0 +1,248 @@
+package cilog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AdminState : state of a Logger shown by AdminHandler
+type AdminState struct {
+	Module        string       `json:"module"`
+	ModuleVer     string       `json:"moduleVer"`
+	MinLevel      string       `json:"minLevel"`
+	PackageLevels string       `json:"packageLevels"`
+	Writer        *WriterStats `json:"writer,omitempty"`
+	// Writers : the writers behind the sink in the order of Add, Writer is the first of them
+	Writers []WriterStats `json:"writers,omitempty"`
+	// Dropped : the records dropped by the queues of the branches of a FanoutSink, in the order of Add
+	Dropped []int64 `json:"dropped,omitempty"`
+}
+
+// AdminHandler : http.Handler to view and change a Logger at runtime.
+//
+//	GET  /                                     state of the logger and the writer or the sink (json)
+//	POST /level?level=debug[&package=cache][&ttl=5m]  changes the min level, reverted after ttl if given
+//	POST /rotate                               rotates the log files
+//	POST /flush                                flushes the queued logs
+//
+// the log files of a logger with a sink are the LogWriters of its WriterSink branches
+//
+// mount it with http.StripPrefix, e.g. mux.Handle("/debug/log/", http.StripPrefix("/debug/log", h))
+type AdminHandler struct {
+	logger *Logger
+	mux    *http.ServeMux
+
+	mu      sync.Mutex
+	reverts map[string]*revert
+	gen     uint64
+}
+
+type revert struct {
+	timer *time.Timer
+	level Level
+	set   bool
+	// gen : the generation of the timer, the callback of a stopped timer may be already running
+	gen uint64
+}
+
+// NewAdminHandler :
+func NewAdminHandler(l *Logger) *AdminHandler {
+	h := &AdminHandler{logger: l, mux: http.NewServeMux(), reverts: map[string]*revert{}}
+	h.mux.HandleFunc("/", h.serveState)
+	h.mux.HandleFunc("/level", h.serveLevel)
+	h.mux.HandleFunc("/rotate", h.serveRotate)
+	h.mux.HandleFunc("/flush", h.serveFlush)
+	return h
+}
+
+// writers : the writer of the logger, or the writers of the WriterSink branches of its sink
+func (h *AdminHandler) writers() []io.Writer {
+	if s := h.logger.GetSink(); s != nil {
+		return sinkWriters(s, nil)
+	}
+	if w := h.logger.GetWriter(); w != nil {
+		return []io.Writer{w}
+	}
+	return nil
+}
+
+func sinkWriters(s Sink, ws []io.Writer) []io.Writer {
+	switch v := s.(type) {
+	case *WriterSink:
+		ws = append(ws, v.Writer())
+	case *FanoutSink:
+		for _, b := range v.branches {
+			ws = sinkWriters(b.sink, ws)
+		}
+	}
+	return ws
+}
+
+// ServeHTTP :
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// State :
+func (h *AdminHandler) State() AdminState {
+	s := AdminState{
+		Module:        h.logger.GetModule(),
+		ModuleVer:     h.logger.GetModuleVer(),
+		MinLevel:      h.logger.GetMinLevel().String(),
+		PackageLevels: h.logger.GetPackageLevels(),
+	}
+	for _, w := range h.writers() {
+		if sw, ok := w.(interface{ Stats() WriterStats }); ok {
+			s.Writers = append(s.Writers, sw.Stats())
+		}
+	}
+	if len(s.Writers) > 0 {
+		s.Writer = &s.Writers[0]
+	}
+	if h.logger.GetSink() == nil {
+		s.Writers = nil
+	}
+	if ds, ok := h.logger.GetSink().(interface{ Dropped() []int64 }); ok {
+		s.Dropped = ds.Dropped()
+	}
+	return s
+}
+
+func (h *AdminHandler) writeState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.State())
+}
+
+func (h *AdminHandler) serveState(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.writeState(w)
+}
+
+func (h *AdminHandler) serveLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lvl, err := LevelFromString(r.FormValue("level"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if v := r.FormValue("ttl"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+			http.Error(w, "invalid ttl ["+v+"]", http.StatusBadRequest)
+			return
+		}
+	}
+	pkg := r.FormValue("package")
+	if _, err := filepath.Match(pkg, ""); err != nil {
+		http.Error(w, "invalid package pattern ["+pkg+"], "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.SetLevel(pkg, lvl, ttl)
+	h.writeState(w)
+}
+
+func (h *AdminHandler) serveRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var rotated bool
+	for _, v := range h.writers() {
+		rw, ok := v.(interface{ Rotate() error })
+		if !ok {
+			continue
+		}
+		rotated = true
+		if err := rw.Rotate(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if !rotated {
+		http.Error(w, "writer does not support rotate", http.StatusBadRequest)
+		return
+	}
+	h.writeState(w)
+}
+
+func (h *AdminHandler) serveFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// the logger flushes its sink or its writer
+	if err := h.logger.Flush(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeState(w)
+}
+
+// SetLevel : sets the min level of pkg (the global min level if pkg is empty).
+// if ttl is positive, the previous level is restored after ttl.
+func (h *AdminHandler) SetLevel(pkg string, lvl Level, ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.setLevel(pkg, lvl, ttl)
+}
+
+func (h *AdminHandler) setLevel(pkg string, lvl Level, ttl time.Duration) {
+	rv, pending := h.reverts[pkg]
+	if pending {
+		rv.timer.Stop()
+		delete(h.reverts, pkg)
+	} else if ttl > 0 {
+		// keep the level before the first temporary change
+		rv = &revert{}
+		if pkg == "" {
+			rv.level, rv.set = h.logger.GetMinLevel(), true
+		} else {
+			rv.level, rv.set = h.logger.packageLevel(pkg)
+		}
+	}
+
+	if pkg == "" {
+		h.logger.SetMinLevel(lvl)
+	} else {
+		h.logger.SetPackageLevel(pkg, lvl)
+	}
+
+	if ttl > 0 {
+		h.gen++
+		gen := h.gen
+		rv.gen = gen
+		rv.timer = time.AfterFunc(ttl, func() { h.revert(pkg, rv, gen) })
+		h.reverts[pkg] = rv
+	}
+}
+
+func (h *AdminHandler) revert(pkg string, rv *revert, gen uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.reverts[pkg] != rv || rv.gen != gen {
+		return
+	}
+	delete(h.reverts, pkg)
+	switch {
+	case pkg == "":
+		h.logger.SetMinLevel(rv.level)
+	case rv.set:
+		h.logger.SetPackageLevel(pkg, rv.level)
+	default:
+		h.logger.RemovePackageLevel(pkg)
+	}
+}
@@ -0,0 +1,203 @@
+package cilog_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func adminRequest(t *testing.T, h http.Handler, method string, target string) (int, cilog.AdminState) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	var state cilog.AdminState
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, state
+}
+
+func TestAdminHandler_State(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	logger.SetPackageLevels("cache=debug")
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+
+	code, state := adminRequest(t, cilog.NewAdminHandler(logger), http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "module", state.Module)
+	assert.Equal(t, "1.0", state.ModuleVer)
+	assert.Equal(t, "info", state.MinLevel)
+	assert.Equal(t, "cache=debug", state.PackageLevels)
+	if assert.NotNil(t, state.Writer) {
+		assert.Equal(t, w.Stats(), *state.Writer)
+		assert.NotEqual(t, "", state.Writer.Path)
+	}
+}
+
+func TestAdminHandler_Level(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.INFO)
+	h := cilog.NewAdminHandler(logger)
+
+	code, state := adminRequest(t, h, http.MethodPost, "/level?level=debug")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", state.MinLevel)
+	assert.Nil(t, state.Writer)
+
+	code, state = adminRequest(t, h, http.MethodPost, "/level?level=warning&package=cache")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "cache=warning", state.PackageLevels)
+
+	code, _ = adminRequest(t, h, http.MethodPost, "/level?level=unknown")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = adminRequest(t, h, http.MethodPost, "/level?level=debug&ttl=abc")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = adminRequest(t, h, http.MethodGet, "/level?level=debug")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	code, _ = adminRequest(t, h, http.MethodPost, "/rotate")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAdminHandler_LevelTTL(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.INFO)
+	h := cilog.NewAdminHandler(logger)
+
+	adminRequest(t, h, http.MethodPost, "/level?level=debug&ttl=100ms")
+	adminRequest(t, h, http.MethodPost, "/level?level=report&ttl=100ms")
+	adminRequest(t, h, http.MethodPost, "/level?level=debug&package=cache&ttl=100ms")
+	assert.Equal(t, cilog.REPORT, logger.GetMinLevel())
+	assert.Equal(t, "cache=debug", logger.GetPackageLevels())
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, cilog.INFO, logger.GetMinLevel())
+	assert.Equal(t, "", logger.GetPackageLevels())
+}
+
+func TestAdminHandler_LevelTTLRepeat(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.INFO)
+	h := cilog.NewAdminHandler(logger)
+
+	// the revert of the first ttl fires while the second is being set, it must not revert the second
+	h.SetLevel("cache", cilog.DEBUG, time.Millisecond)
+	cilog.SetLevelAfter(h, 50*time.Millisecond, "cache", cilog.WARNING, time.Hour)
+	h.SetLevel("", cilog.DEBUG, time.Millisecond)
+	cilog.SetLevelAfter(h, 50*time.Millisecond, "", cilog.WARNING, time.Hour)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "cache=warning", logger.GetPackageLevels())
+	assert.Equal(t, cilog.WARNING, logger.GetMinLevel())
+}
+
+func TestAdminHandler_SinkDropped(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	f := cilog.NewFanoutSink().
+		AddWriter(slow, nil, cilog.DEBUG, 1).
+		AddWriter(&syncStringWriter{}, nil, cilog.DEBUG, 0)
+	logger := cilog.New(nil, "module", "1.0", cilog.DEBUG)
+	logger.SetSink(f)
+	for i := 0; i < 10; i++ {
+		logger.Log(1, cilog.INFO, "abc", time.Now())
+	}
+
+	code, state := adminRequest(t, cilog.NewAdminHandler(logger), http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, state.Writer)
+	assert.Equal(t, f.Dropped(), state.Dropped)
+	assert.True(t, state.Dropped[0] > 0, "dropped %v", state.Dropped)
+
+	close(slow.release)
+	assert.NoError(t, f.Close())
+}
+
+func TestAdminHandler_RotateFlush(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.Start()
+	defer w.Stop()
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	h := cilog.NewAdminHandler(logger)
+
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	code, state := adminRequest(t, h, http.MethodPost, "/flush")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 0, state.Writer.QueueLen)
+	assert.Equal(t, 1024, state.Writer.QueueCap)
+	bytes := state.Writer.Bytes
+	assert.NotEqual(t, int64(0), bytes)
+
+	code, state = adminRequest(t, h, http.MethodPost, "/rotate")
+	assert.Equal(t, http.StatusOK, code)
+	now := time.Now()
+	assert.Equal(t, filepath.Join(dir, now.Format("2006-01"), now.Format("2006-01-02")+"[1]_module.log"), state.Writer.Path)
+	assert.Equal(t, int64(0), state.Writer.Size)
+	assert.Equal(t, bytes, state.Writer.Bytes)
+}
+
+func TestAdminHandler_Sinks(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	c := cilog.Config{
+		Dir: filepath.Join(dir, "1"), Module: "module", BufferSize: 16, MinLevel: cilog.INFO,
+		Sinks: []cilog.SinkConfig{{Dir: filepath.Join(dir, "2"), Module: "module", BufferSize: 16}},
+	}
+	logger, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	h := cilog.NewAdminHandler(logger)
+
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	code, state := adminRequest(t, h, http.MethodPost, "/flush")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Equal(t, 2, len(state.Writers)) && assert.NotNil(t, state.Writer) {
+		assert.Equal(t, state.Writers[0], *state.Writer)
+		for _, s := range state.Writers {
+			assert.NotEqual(t, int64(0), s.Bytes)
+			assert.Equal(t, 0, s.QueueLen)
+		}
+	}
+
+	code, state = adminRequest(t, h, http.MethodPost, "/rotate")
+	assert.Equal(t, http.StatusOK, code)
+	now := time.Now()
+	for i, s := range state.Writers {
+		assert.Equal(t, filepath.Join(dir, strconv.Itoa(i+1), now.Format("2006-01"), now.Format("2006-01-02")+"[1]_module.log"), s.Path)
+	}
+}
+
+func TestAdminHandler_InvalidPackage(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.INFO)
+	h := cilog.NewAdminHandler(logger)
+
+	code, _ := adminRequest(t, h, http.MethodPost, "/level?level=debug&package=cache[")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "", logger.GetPackageLevels())
+
+	code, state := adminRequest(t, h, http.MethodPost, "/level?level=debug&package=cache/*")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "cache/*=debug", state.PackageLevels)
+}
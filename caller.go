@@ -0,0 +1,113 @@
+package cilog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CallerMode : how the caller of a record is written
+type CallerMode int
+
+// caller modes of a Logger
+const (
+	// CallerShort : the base of the package and the base name of the file, "package1::src.go:56"
+	CallerShort CallerMode = iota
+	// CallerFull : the package path and the file path, "github.com/castisdev/package1::/src/package1/src.go:56"
+	CallerFull
+	// CallerNone : no caller, the caller is not captured unless the package levels are set
+	CallerNone
+)
+
+// CallerModeFromString : "short", "full" or "none"
+func CallerModeFromString(s string) (CallerMode, error) {
+	switch strings.ToLower(s) {
+	case "", "short":
+		return CallerShort, nil
+	case "full":
+		return CallerFull, nil
+	case "none", "off":
+		return CallerNone, nil
+	}
+	return CallerShort, fmt.Errorf("caller [%s] is not supported, use short, full or none", s)
+}
+
+// callerOption : the caller of a Logger
+type callerOption struct {
+	mode         CallerMode
+	function     bool
+	trimPrefixes []string
+	skip         int
+}
+
+// SetCaller : sets how the caller of a record is written. the function of the caller is written after the package
+// if function is true, "package1.(*Server).Serve::src.go:56". trimPrefixes are removed from the file path of CallerFull,
+// the first matched one is removed, e.g. "/home/build/src/"
+func (l *Logger) SetCaller(mode CallerMode, function bool, trimPrefixes ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.mode = mode
+	l.caller.function = function
+	l.caller.trimPrefixes = append([]string(nil), trimPrefixes...)
+}
+
+// SetCaller :
+func SetCaller(mode CallerMode, function bool, trimPrefixes ...string) {
+	std.SetCaller(mode, function, trimPrefixes...)
+}
+
+// SetCallerSkip : frames skipped in addition to calldepth, so that a library wrapping the logger
+// writes the caller of the library without computing calldepth, e.g. 1 for a function calling Infof.
+// the stack trace and the package levels are of the same caller
+func (l *Logger) SetCallerSkip(skip int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.skip = skip
+}
+
+// SetCallerSkip :
+func SetCallerSkip(skip int) {
+	std.SetCallerSkip(skip)
+}
+
+// record : the package, the function and the file of the caller written in a record
+func (o callerOption) record(c *callerInfo) (pkg string, function string, file string) {
+	if c == unknownCaller {
+		return c.pkg, "", c.file
+	}
+	pkg, file = c.pkg, c.file
+	full := functionPackage(c.frame.Function)
+	if o.mode == CallerFull {
+		pkg = full
+		file = o.trim(c.frame.File)
+	}
+	if o.function && len(c.frame.Function) > len(full) {
+		function = c.frame.Function[len(full)+1:]
+	}
+	return pkg, function, file
+}
+
+func (o callerOption) trim(file string) string {
+	for _, p := range o.trimPrefixes {
+		if strings.HasPrefix(file, p) {
+			return file[len(p):]
+		}
+	}
+	return file
+}
+
+// appendCaller : "package1::src.go:56", "package1.(*Server).Serve::src.go:56" with the function, empty if no caller
+func appendCaller(buf []byte, r *Record) []byte {
+	if r.Package == "" && r.File == "" {
+		return buf
+	}
+	buf = append(buf, r.Package...)
+	if r.Function != "" {
+		buf = append(buf, '.')
+		buf = append(buf, r.Function...)
+	}
+	buf = append(buf, "::"...)
+	buf = append(buf, r.File...)
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(r.Line), 10)
+}
@@ -0,0 +1,145 @@
+package cilog_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_SetCaller(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(file) + "/"
+
+	tests := []struct {
+		name         string
+		mode         cilog.CallerMode
+		function     bool
+		trimPrefixes []string
+		caller       string
+	}{
+		{name: "short", mode: cilog.CallerShort, caller: "cilog_test::caller_test.go:"},
+		{name: "short function", mode: cilog.CallerShort, function: true,
+			caller: "cilog_test.TestLogger_SetCaller.func1::caller_test.go:"},
+		{name: "full", mode: cilog.CallerFull, caller: "github.com/castisdev/cilog_test::" + file + ":"},
+		{name: "full function trim", mode: cilog.CallerFull, function: true, trimPrefixes: []string{"/not/matched/", dir},
+			caller: "github.com/castisdev/cilog_test.TestLogger_SetCaller.func1::caller_test.go:"},
+		{name: "none", mode: cilog.CallerNone, caller: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &stringWriter{}
+			logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+			logger.SetCaller(tt.mode, tt.function, tt.trimPrefixes...)
+			logger.Log(1, cilog.INFO, "abc", time.Now())
+			_, _, line, _ := runtime.Caller(0)
+
+			caller := tt.caller
+			if caller != "" {
+				caller += strconv.Itoa(line - 1)
+			}
+			assert.True(t, strings.HasSuffix(w.writed, ",Information,"+caller+",,abc\n"), w.writed)
+
+			r, err := cilog.ParseLine(w.writed)
+			if assert.Nil(t, err) {
+				assert.Equal(t, caller, callerOf(r))
+			}
+		})
+	}
+}
+
+// callerOf : the caller column of r
+func callerOf(r cilog.Record) string {
+	if r.Package == "" && r.File == "" {
+		return ""
+	}
+	s := r.Package
+	if r.Function != "" {
+		s += "." + r.Function
+	}
+	return s + "::" + r.File + ":" + strconv.Itoa(r.Line)
+}
+
+func TestLogger_SetCaller_JSON(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetEncoder(cilog.JSONEncoder{})
+	logger.SetCaller(cilog.CallerShort, true)
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	_, _, line, _ := runtime.Caller(0)
+	assert.Contains(t, w.writed, `"package":"cilog_test","function":"TestLogger_SetCaller_JSON","file":"caller_test.go","line":`+strconv.Itoa(line-1)+`,`)
+
+	r, err := cilog.ParseLine(w.writed)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "cilog_test", r.Package)
+		assert.Equal(t, "TestLogger_SetCaller_JSON", r.Function)
+	}
+
+	w.writed = ""
+	logger.SetCaller(cilog.CallerNone, true)
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.NotContains(t, w.writed, `"package"`)
+	assert.NotContains(t, w.writed, `"line"`)
+}
+
+func TestLogger_SetCaller_PackageLevels(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	logger.SetCaller(cilog.CallerNone, false)
+	logger.SetPackageLevel("cilog_test", cilog.DEBUG)
+	logger.Log(1, cilog.DEBUG, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",Debug,,,abc\n"), w.writed)
+}
+
+// logWrapper : a library wrapping the logger
+func logWrapper(logger *cilog.Logger, msg string) {
+	logger.Logf(1, cilog.INFO, time.Time{}, "wrapped %s", msg)
+}
+
+func TestLogger_SetCallerSkip(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetCallerSkip(1)
+	logger.SetStackTrace(cilog.INFO, 1)
+	logWrapper(logger, "abc")
+	_, file, line, _ := runtime.Caller(0)
+	assert.Equal(t, 2, strings.Count(w.writed, "\n"), w.writed)
+	assert.Contains(t, w.writed, ",cilog_test::caller_test.go:"+strconv.Itoa(line-1)+",,wrapped abc\n")
+	assert.Contains(t, w.writed, "\tat github.com/castisdev/cilog_test.TestLogger_SetCallerSkip("+file+":"+strconv.Itoa(line-1)+")\n")
+
+	logger.SetStackTrace(0, 0)
+	logger.SetPackageLevel("cilog_test", cilog.WARNING)
+	w.writed = ""
+	logWrapper(logger, "abc")
+	assert.Equal(t, "", w.writed)
+}
+
+func TestLogger_SetCallerSkip_Recover(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetCallerSkip(1)
+	var line int
+	func() {
+		defer logger.Recover()
+		_, _, line, _ = runtime.Caller(0)
+		panic("abc")
+	}()
+	assert.Contains(t, w.writed, ",cilog_test::caller_test.go:"+strconv.Itoa(line+1)+",,panic: abc\n")
+}
+
+func TestCallerModeFromString(t *testing.T) {
+	for s, mode := range map[string]cilog.CallerMode{
+		"": cilog.CallerShort, "short": cilog.CallerShort, "Full": cilog.CallerFull, "none": cilog.CallerNone,
+	} {
+		m, err := cilog.CallerModeFromString(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, mode, m, s)
+	}
+	_, err := cilog.CallerModeFromString("long")
+	assert.NotNil(t, err)
+}
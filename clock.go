@@ -0,0 +1,47 @@
+package cilog
+
+import "time"
+
+// Clock : the time of records and of log files, e.g. a fake clock of tests driving the day rollover
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock : time.Now
+var SystemClock Clock = systemClock{}
+
+// SetClock : the clock of the records logged with the zero time, e.g. by Infof. SystemClock if c is nil
+func (l *Logger) SetClock(c Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+}
+
+// SetClock : the clock of Write, WriteLevel, Reopen and Rotate, SystemClock if c is nil.
+// it must be called before the writer is used
+func (w *LogWriter) SetClock(c Clock) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.clock = c
+	for _, lf := range w.levelFiles {
+		lf.w.SetClock(c)
+	}
+}
+
+func (w *LogWriter) now() time.Time {
+	if w.clock == nil {
+		return time.Now()
+	}
+	return w.clock.Now()
+}
+
+// SetClock :
+func SetClock(c Clock) {
+	std.SetClock(c)
+}
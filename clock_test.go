@@ -0,0 +1,144 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestLogger_SetClock(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.Local)}
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetClock(clock)
+	logger.Log(1, cilog.INFO, "abc", time.Time{})
+	assert.True(t, strings.HasPrefix(w.writed, "module,1.0,2009-11-23,15:21:30.123456,Information,"), w.writed)
+
+	w.writed = ""
+	logger.Log(1, cilog.INFO, "abc", time.Date(2010, 1, 2, 0, 0, 0, 0, time.Local))
+	assert.True(t, strings.HasPrefix(w.writed, "module,1.0,2010-01-02,00:00:00.000000,Information,"), w.writed)
+}
+
+func TestLogWriter_SetClock_DayRollover(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		idv4, _ := uuid.NewRandom()
+		dir := path.Join("ut.dir", idv4.String())
+		os.MkdirAll(dir, 0775)
+		defer os.RemoveAll(dir)
+
+		clock := &fakeClock{t: time.Date(2009, 11, 23, 23, 59, 59, 0, time.Local)}
+		w := cilog.NewLogWriter(dir, "module", 1024)
+		w.SetClock(clock)
+		if async {
+			w.Start()
+		}
+		logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+		logger.SetClock(clock)
+		logger.Log(1, cilog.INFO, "day1", time.Time{})
+		w.Write([]byte("day1 write\n"))
+		clock.Add(time.Second)
+		logger.Log(1, cilog.INFO, "day2", time.Time{})
+		if async {
+			w.Stop()
+		} else {
+			w.Close()
+		}
+
+		b1, err := ioutil.ReadFile(filepath.Join(dir, "2009-11", "2009-11-23_module.log"))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(b1), "\n"), string(b1))
+		assert.True(t, strings.HasSuffix(string(b1), ",,day1\nday1 write\n"), string(b1))
+		b2, err := ioutil.ReadFile(filepath.Join(dir, "2009-11", "2009-11-24_module.log"))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(b2), "module,1.0,2009-11-24,00:00:00.000000,"), string(b2))
+	}
+}
+
+func TestLogWriter_WriteLevelWithTime_Async(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	// the queue keeps the time of the record even if the clock passes the day before it is written
+	clock := &fakeClock{t: time.Date(2009, 11, 23, 23, 59, 59, 0, time.Local)}
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.SetClock(clock)
+	w.StartWithBufferSize(16)
+	w.WriteLevelWithTime([]byte("abc\n"), cilog.INFO, clock.Now())
+	clock.Add(time.Hour)
+	w.Stop()
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "2009-11", "2009-11-23_module.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc\n", string(b))
+}
+
+func TestLogWriter_OlderRecord(t *testing.T) {
+	for _, rotateSize := range []int64{1024, 5} {
+		idv4, _ := uuid.NewRandom()
+		dir := path.Join("ut.dir", idv4.String())
+		os.MkdirAll(dir, 0775)
+		defer os.RemoveAll(dir)
+
+		var mu sync.Mutex
+		var closed []cilog.ClosedFile
+		w := cilog.NewLogWriter(dir, "module", rotateSize)
+		w.AddCloseHook(func(f cilog.ClosedFile) {
+			mu.Lock()
+			defer mu.Unlock()
+			closed = append(closed, f)
+		})
+
+		// a record logged before midnight is written after a record of the next day
+		day2 := time.Date(2009, 11, 24, 0, 0, 0, 0, time.Local)
+		w.WriteLevelWithTime([]byte("abcdef\n"), cilog.INFO, day2)
+		w.WriteLevelWithTime([]byte("abcdef\n"), cilog.INFO, day2.Add(-time.Second))
+		w.WriteLevelWithTime([]byte("abcdef\n"), cilog.INFO, day2)
+		w.Close()
+
+		mu.Lock()
+		for _, f := range closed {
+			assert.NotEqual(t, cilog.CloseByDay, f.Reason, "%v", f)
+			assert.True(t, strings.HasPrefix(filepath.Base(f.Path), "2009-11-24"), f.Path)
+		}
+		mu.Unlock()
+		_, err := os.Stat(filepath.Join(dir, "2009-11", "2009-11-23_module.log"))
+		assert.True(t, os.IsNotExist(err), "%v", err)
+		if rotateSize == 5 {
+			assert.Equal(t, 3, len(closed))
+			continue
+		}
+		if assert.Equal(t, 1, len(closed)) {
+			assert.Equal(t, cilog.CloseByShutdown, closed[0].Reason)
+			assert.Equal(t, int64(3), closed[0].Lines)
+			assert.Equal(t, day2, closed[0].End)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package cilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultRotateSize : rotate size of file writers if not configured
+const DefaultRotateSize = 10 * 1024 * 1024
+
+// DefaultSinkQueueSize : queue size of the sinks of Config if not configured
+const DefaultSinkQueueSize = 1024
+
+// Config : configuration of a Logger and its writers
+//
+//	writer: file
+//	dir: /var/log/castis/example
+//	module: example
+//	moduleVer: 1.0.0
+//	rotateSize: 10485760
+//	bufferSize: 1024
+//	minLevel: info
+//	packageLevels: cache=debug
+//	retention: 30
+//	sinks:
+//	  - writer: stderr
+type Config struct {
+	// Writer : "file" (default), "stdout" or "stderr"
+	Writer    string `yaml:"writer" json:"writer"`
+	Dir       string `yaml:"dir" json:"dir"`
+	Module    string `yaml:"module" json:"module"`
+	ModuleVer string `yaml:"moduleVer" json:"moduleVer"`
+	// RotateSize : DefaultRotateSize if 0
+	RotateSize int64 `yaml:"rotateSize" json:"rotateSize"`
+	// BufferSize : the file writer writes asynchronously with a queue of BufferSize if positive
+	BufferSize int `yaml:"bufferSize" json:"bufferSize"`
+	// MinLevel : DEBUG if not set
+	MinLevel      Level  `yaml:"minLevel,omitempty" json:"minLevel,omitempty"`
+	PackageLevels string `yaml:"packageLevels" json:"packageLevels"`
+	// Encoder : "csv" (default) or "json"
+	Encoder string `yaml:"encoder" json:"encoder"`
+	// TimeFormat, TimePrecision and TimeZone : the time of the encoders of the writer and the sinks,
+	// "default", "iso8601" or "epoch", "ms", "us" (default) or "ns", and "local", "utc" or a zone name like "Asia/Seoul".
+	// the time is written in its own location, the local time, if TimeZone is empty
+	TimeFormat    string `yaml:"timeFormat" json:"timeFormat"`
+	TimePrecision string `yaml:"timePrecision" json:"timePrecision"`
+	TimeZone      string `yaml:"timeZone" json:"timeZone"`
+	// Retention : days to keep log files, 0 keeps all
+	Retention int `yaml:"retention" json:"retention"`
+	// StackLevel, StackDepth and StackExcludes : records of StackLevel or above have the stack of the caller,
+	// no stack if StackLevel is not set, see Logger.SetStackTrace
+	StackLevel    Level    `yaml:"stackLevel,omitempty" json:"stackLevel,omitempty"`
+	StackDepth    int      `yaml:"stackDepth" json:"stackDepth"`
+	StackExcludes []string `yaml:"stackExcludes" json:"stackExcludes"`
+	// Caller, CallerFunction and CallerTrimPrefixes : "short" (default), "full" or "none",
+	// the function of the caller and the prefixes removed from the file path of "full", see Logger.SetCaller
+	Caller             string   `yaml:"caller" json:"caller"`
+	CallerFunction     bool     `yaml:"callerFunction" json:"callerFunction"`
+	CallerTrimPrefixes []string `yaml:"callerTrimPrefixes" json:"callerTrimPrefixes"`
+	// Hostname, PID and StaticFields : static fields written in every record, "host=node1 pid=1234 dc=kr1".
+	// environment variables in the values of StaticFields are expanded, e.g. "${INSTANCE_ID}"
+	Hostname     bool              `yaml:"hostname" json:"hostname"`
+	PID          bool              `yaml:"pid" json:"pid"`
+	StaticFields map[string]string `yaml:"staticFields" json:"staticFields"`
+	// Sinks : additional writers, the logger writes to a FanoutSink of the writer and the sinks if not empty
+	Sinks []SinkConfig `yaml:"sinks" json:"sinks"`
+}
+
+// SinkConfig : an additional writer of Config, Module is the module of Config if empty
+type SinkConfig struct {
+	// Writer : "file" (default), "stdout", "stderr" or "syslog"
+	Writer     string `yaml:"writer" json:"writer"`
+	Dir        string `yaml:"dir" json:"dir"`
+	Module     string `yaml:"module" json:"module"`
+	RotateSize int64  `yaml:"rotateSize" json:"rotateSize"`
+	BufferSize int    `yaml:"bufferSize" json:"bufferSize"`
+	Retention  int    `yaml:"retention" json:"retention"`
+	// Network, Address, Facility and Format : syslog writer, e.g. "udp", "127.0.0.1:514", "local0", "rfc5424"
+	Network  string `yaml:"network" json:"network"`
+	Address  string `yaml:"address" json:"address"`
+	Facility string `yaml:"facility" json:"facility"`
+	Format   string `yaml:"format" json:"format"`
+	// MinLevel : only records of MinLevel or above are written to the sink, all records if not set
+	MinLevel Level `yaml:"minLevel,omitempty" json:"minLevel,omitempty"`
+	// Encoder : "csv" (default) or "json"
+	Encoder string `yaml:"encoder" json:"encoder"`
+	// QueueSize : the sink is written by its own goroutine with a queue of QueueSize, DefaultSinkQueueSize if 0,
+	// so that a slow sink does not block the others. records are dropped if the queue is full.
+	// the sink is written synchronously if -1, which is not allowed for syslog writers
+	QueueSize int `yaml:"queueSize" json:"queueSize"`
+}
+
+// ParseConfig : format is "yaml" or "json"
+func ParseConfig(data []byte, format string) (Config, error) {
+	var c Config
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		err = yaml.UnmarshalStrict(data, &c)
+	case "json":
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.DisallowUnknownFields()
+		err = d.Decode(&c)
+	default:
+		return c, fmt.Errorf("cilog config: unknown format [%s]", format)
+	}
+	if err != nil {
+		return c, fmt.Errorf("cilog config: %v", err)
+	}
+	return c, nil
+}
+
+// LoadConfig : the format is decided by the extension of path, ".json" or yaml otherwise
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	format := "yaml"
+	if isJSONPath(path) {
+		format = "json"
+	}
+	c, err := ParseConfig(data, format)
+	if err != nil {
+		return c, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+func isJSONPath(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".json"
+}
+
+// Validate :
+func (c Config) Validate() error {
+	if c.Module == "" {
+		return errors.New("cilog config: module is required")
+	}
+	if c.MinLevel != 0 && c.MinLevel.String() == "" {
+		return fmt.Errorf("cilog config: invalid minLevel %d", int(c.MinLevel))
+	}
+	if _, err := parsePackageLevels(c.PackageLevels); err != nil {
+		return fmt.Errorf("cilog config: packageLevels: %v", err)
+	}
+	if c.StackLevel != 0 && c.StackLevel.String() == "" {
+		return fmt.Errorf("cilog config: invalid stackLevel %d", int(c.StackLevel))
+	}
+	if c.StackDepth < 0 {
+		return fmt.Errorf("cilog config: invalid stackDepth %d", c.StackDepth)
+	}
+	if _, err := CallerModeFromString(c.Caller); err != nil {
+		return fmt.Errorf("cilog config: %v", err)
+	}
+	if _, err := c.timeFormat(); err != nil {
+		return fmt.Errorf("cilog config: %v", err)
+	}
+	for k := range c.StaticFields {
+		if k == "" || strings.ContainsAny(k, "=, \t\r\n") {
+			return fmt.Errorf("cilog config: invalid staticFields key [%s]", k)
+		}
+	}
+	if err := c.sink().validate(); err != nil {
+		return fmt.Errorf("cilog config: %v", err)
+	}
+	for i, s := range c.Sinks {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("cilog config: sinks[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (c Config) sink() SinkConfig {
+	return SinkConfig{
+		Writer:     c.Writer,
+		Dir:        c.Dir,
+		Module:     c.Module,
+		RotateSize: c.RotateSize,
+		BufferSize: c.BufferSize,
+		Retention:  c.Retention,
+		Encoder:    c.Encoder,
+	}
+}
+
+// sinks : the config of all writers
+func (c Config) sinks() []SinkConfig {
+	return append([]SinkConfig{c.sink()}, c.Sinks...)
+}
+
+func (s SinkConfig) validate() error {
+	switch s.Writer {
+	case "", "file":
+		if s.Dir == "" {
+			return errors.New("dir is required for file writer")
+		}
+	case "stdout", "stderr":
+	case "syslog":
+		switch s.Network {
+		case "udp", "tcp", "unix", "unixgram":
+		default:
+			return fmt.Errorf("network [%s] is not supported for syslog writer, use udp, tcp, unix or unixgram", s.Network)
+		}
+		if s.Address == "" {
+			return errors.New("address is required for syslog writer")
+		}
+		if _, err := FacilityFromString(s.Facility); s.Facility != "" && err != nil {
+			return err
+		}
+		switch strings.ToLower(s.Format) {
+		case "", "rfc5424", "rfc3164":
+		default:
+			return fmt.Errorf("syslog format [%s] is not supported, use rfc5424 or rfc3164", s.Format)
+		}
+	default:
+		return fmt.Errorf("writer [%s] is not supported, use file, stdout, stderr or syslog", s.Writer)
+	}
+	if s.RotateSize < 0 {
+		return fmt.Errorf("invalid rotateSize %d", s.RotateSize)
+	}
+	if s.BufferSize < 0 {
+		return fmt.Errorf("invalid bufferSize %d", s.BufferSize)
+	}
+	if s.Retention < 0 {
+		return fmt.Errorf("invalid retention %d", s.Retention)
+	}
+	if _, ok := EncoderFromString(s.Encoder); !ok {
+		return fmt.Errorf("encoder [%s] is not supported, use csv or json", s.Encoder)
+	}
+	if s.MinLevel != 0 && s.MinLevel.String() == "" {
+		return fmt.Errorf("invalid minLevel %d", int(s.MinLevel))
+	}
+	if s.QueueSize < -1 {
+		return fmt.Errorf("invalid queueSize %d", s.QueueSize)
+	}
+	if s.QueueSize == -1 && s.Writer == "syslog" {
+		// a network writer may block on dialing
+		return errors.New("syslog writer must have a queue, queueSize -1 is not allowed")
+	}
+	return nil
+}
+
+// queueSize : the queue size of FanoutSink.Add, 0 is synchronous
+func (s SinkConfig) queueSize() int {
+	switch {
+	case s.QueueSize == 0:
+		return DefaultSinkQueueSize
+	case s.QueueSize < 0:
+		return 0
+	}
+	return s.QueueSize
+}
+
+func (s SinkConfig) build(module string) io.Writer {
+	switch s.Writer {
+	case "stdout":
+		return os.Stdout
+	case "stderr":
+		return os.Stderr
+	case "syslog":
+		facility := USER
+		if s.Facility != "" {
+			facility, _ = FacilityFromString(s.Facility)
+		}
+		w := NewSyslogWriter(s.Network, s.Address, facility, s.Module)
+		if strings.ToLower(s.Format) == "rfc3164" {
+			w.SetFormat(RFC3164)
+		}
+		return w
+	}
+	if s.Module != "" {
+		module = s.Module
+	}
+	rotateSize := s.RotateSize
+	if rotateSize == 0 {
+		rotateSize = DefaultRotateSize
+	}
+	w := NewLogWriter(s.Dir, module, rotateSize)
+	w.SetRetention(s.Retention)
+	if s.BufferSize > 0 {
+		w.StartWithBufferSize(s.BufferSize)
+	}
+	return w
+}
+
+// Build : builds a Logger, asynchronous file writers are already started.
+// the writers can be stopped with Logger.Close
+func (c Config) Build() (*Logger, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	l := &Logger{}
+	l.apply(c, c.buildOutput())
+	return l, nil
+}
+
+// Install : builds a Logger and installs it as the standard logger
+func (c Config) Install() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	std.apply(c, c.buildOutput())
+	return nil
+}
+
+// timeFormat : the time format of the encoders
+func (c Config) timeFormat() (TimeFormat, error) {
+	var tf TimeFormat
+	var err error
+	if tf.Layout, err = TimeLayoutFromString(c.TimeFormat); err != nil {
+		return tf, err
+	}
+	if tf.Precision, err = TimePrecisionFromString(c.TimePrecision); err != nil {
+		return tf, err
+	}
+	if tf.Location, err = LocationFromString(c.TimeZone); err != nil {
+		return tf, fmt.Errorf("time zone [%s]: %v", c.TimeZone, err)
+	}
+	return tf, nil
+}
+
+func (c Config) minLevel() Level {
+	if c.MinLevel == 0 {
+		return DEBUG
+	}
+	return c.MinLevel
+}
+
+// output : the writer or the sink of a Logger
+type output struct {
+	writer  io.Writer
+	encoder Encoder
+	sink    Sink
+}
+
+func (o output) close() error {
+	if o.sink != nil {
+		if c, ok := o.sink.(io.Closer); ok {
+			return c.Close()
+		}
+		return nil
+	}
+	return CloseWriter(o.writer)
+}
+
+func (c Config) buildOutput() output {
+	tf, _ := c.timeFormat()
+	enc, _ := encoderFromString(c.Encoder, tf)
+	w := c.sink().build(c.Module)
+	if len(c.Sinks) == 0 {
+		return output{writer: w, encoder: enc}
+	}
+	f := NewFanoutSink().AddWriter(w, enc, 0, 0)
+	for _, s := range c.Sinks {
+		sw := s.build(c.Module)
+		// e.g. SyslogWriter takes records as they are
+		if sk, ok := sw.(Sink); ok {
+			f.Add(sk, s.MinLevel, s.queueSize())
+			continue
+		}
+		senc, _ := encoderFromString(s.Encoder, tf)
+		f.AddWriter(sw, senc, s.MinLevel, s.queueSize())
+	}
+	return output{sink: f}
+}
+
+// apply : c must be valid
+func (l *Logger) apply(c Config, o output) {
+	pkgLevels, _ := parsePackageLevels(c.PackageLevels)
+	staticFields := c.staticFields()
+	callerMode, _ := CallerModeFromString(c.Caller)
+	l.mu.Lock()
+	l.writer = o.writer
+	l.encoder = o.encoder
+	l.sink = o.sink
+	l.module = c.Module
+	l.moduleVer = c.ModuleVer
+	l.minLevel = c.minLevel()
+	l.setPackageLevels(pkgLevels)
+	l.staticFields = staticFields
+	l.stack = stackOption{minLevel: c.StackLevel, depth: c.StackDepth, excludes: c.StackExcludes}
+	// the caller skip is of the code wrapping the logger, not of the config
+	l.caller = callerOption{mode: callerMode, function: c.CallerFunction, trimPrefixes: c.CallerTrimPrefixes, skip: l.caller.skip}
+	writing := l.swapWriting()
+	l.mu.Unlock()
+	// the old writers may be closed after apply returns
+	writing.Wait()
+}
+
+// CloseWriter : stops and closes w if it is a LogWriter or an io.Closer other than stdout and stderr
+func CloseWriter(w io.Writer) error {
+	switch v := w.(type) {
+	case *LogWriter:
+		if v.started() {
+			v.Stop()
+			return nil
+		}
+		return v.Close()
+	case *os.File:
+		if v == os.Stdout || v == os.Stderr {
+			return nil
+		}
+		return v.Close()
+	case io.Closer:
+		return v.Close()
+	}
+	return nil
+}
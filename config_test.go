@@ -0,0 +1,260 @@
+package cilog_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseConfig(t *testing.T) {
+	yamlData := `
+writer: file
+dir: /var/log/example
+module: example
+moduleVer: 1.0.0
+rotateSize: 1024
+bufferSize: 16
+minLevel: info
+packageLevels: cache=debug
+retention: 30
+sinks:
+  - writer: stderr
+`
+	jsonData := `{
+	"writer": "file",
+	"dir": "/var/log/example",
+	"module": "example",
+	"moduleVer": "1.0.0",
+	"rotateSize": 1024,
+	"bufferSize": 16,
+	"minLevel": "info",
+	"packageLevels": "cache=debug",
+	"retention": 30,
+	"sinks": [{"writer": "stderr"}]
+}`
+	expected := cilog.Config{
+		Writer:        "file",
+		Dir:           "/var/log/example",
+		Module:        "example",
+		ModuleVer:     "1.0.0",
+		RotateSize:    1024,
+		BufferSize:    16,
+		MinLevel:      cilog.INFO,
+		PackageLevels: "cache=debug",
+		Retention:     30,
+		Sinks:         []cilog.SinkConfig{{Writer: "stderr"}},
+	}
+
+	c, err := cilog.ParseConfig([]byte(yamlData), "yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, c)
+
+	c, err = cilog.ParseConfig([]byte(jsonData), "json")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, c)
+
+	_, err = cilog.ParseConfig([]byte("minLevel: unknown"), "yaml")
+	assert.Error(t, err)
+	_, err = cilog.ParseConfig([]byte("unknownField: 1"), "yaml")
+	assert.Error(t, err)
+	_, err = cilog.ParseConfig([]byte(`{"unknownField": 1}`), "json")
+	assert.Error(t, err)
+	_, err = cilog.ParseConfig([]byte(jsonData), "toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_MarshalZero(t *testing.T) {
+	c := cilog.Config{Sinks: []cilog.SinkConfig{{Writer: "stdout"}}}
+
+	data, err := json.Marshal(c)
+	if assert.NoError(t, err) {
+		// the unset levels are omitted, an empty level is rejected
+		assert.NotContains(t, string(data), `Level"`)
+		var j cilog.Config
+		assert.NoError(t, json.Unmarshal(data, &j))
+		assert.Equal(t, c, j)
+	}
+
+	data, err = yaml.Marshal(c)
+	if assert.NoError(t, err) {
+		var y cilog.Config
+		assert.NoError(t, yaml.Unmarshal(data, &y))
+		assert.Equal(t, cilog.Level(0), y.MinLevel)
+		assert.Equal(t, cilog.Level(0), y.StackLevel)
+		if assert.Len(t, y.Sinks, 1) {
+			assert.Equal(t, cilog.Level(0), y.Sinks[0].MinLevel)
+		}
+		again, err := yaml.Marshal(y)
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), string(again))
+	}
+
+	parsed, err := cilog.ParseConfig(data, "yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, cilog.Level(0), parsed.MinLevel)
+	_, err = cilog.ParseConfig([]byte(`minLevel: ""`), "yaml")
+	assert.Error(t, err)
+	_, err = cilog.ParseConfig([]byte(`{"minLevel":""}`), "json")
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config cilog.Config
+		errMsg string
+	}{
+		{
+			name:   "valid",
+			config: cilog.Config{Dir: "log", Module: "module"},
+		},
+		{
+			name:   "valid stderr",
+			config: cilog.Config{Writer: "stderr", Module: "module", Sinks: []cilog.SinkConfig{{Dir: "log"}}},
+		},
+		{
+			name:   "no module",
+			config: cilog.Config{Dir: "log"},
+			errMsg: "cilog config: module is required",
+		},
+		{
+			name:   "no dir",
+			config: cilog.Config{Module: "module"},
+			errMsg: "cilog config: dir is required for file writer",
+		},
+		{
+			name:   "unknown writer",
+			config: cilog.Config{Writer: "kafka", Module: "module"},
+			errMsg: "cilog config: writer [kafka] is not supported, use file, stdout, stderr or syslog",
+		},
+		{
+			name:   "unknown encoder",
+			config: cilog.Config{Dir: "log", Module: "module", Encoder: "xml"},
+			errMsg: "cilog config: encoder [xml] is not supported, use csv or json",
+		},
+		{
+			name:   "invalid package levels",
+			config: cilog.Config{Dir: "log", Module: "module", PackageLevels: "cache"},
+			errMsg: "cilog config: packageLevels: invalid package level [cache], expected pattern=level",
+		},
+		{
+			name:   "invalid sink",
+			config: cilog.Config{Dir: "log", Module: "module", Sinks: []cilog.SinkConfig{{Writer: "stdout"}, {RotateSize: -1}}},
+			errMsg: "cilog config: sinks[1]: dir is required for file writer",
+		},
+		{
+			name:   "invalid static field",
+			config: cilog.Config{Dir: "log", Module: "module", StaticFields: map[string]string{"a=b": "c"}},
+			errMsg: "cilog config: invalid staticFields key [a=b]",
+		},
+		{
+			name:   "invalid caller",
+			config: cilog.Config{Dir: "log", Module: "module", Caller: "long"},
+			errMsg: "cilog config: caller [long] is not supported, use short, full or none",
+		},
+		{
+			name:   "synchronous sink",
+			config: cilog.Config{Dir: "log", Module: "module", Sinks: []cilog.SinkConfig{{Writer: "stdout", QueueSize: -1}}},
+		},
+		{
+			name:   "invalid queue size",
+			config: cilog.Config{Dir: "log", Module: "module", Sinks: []cilog.SinkConfig{{Writer: "stdout", QueueSize: -2}}},
+			errMsg: "cilog config: sinks[0]: invalid queueSize -2",
+		},
+		{
+			name:   "invalid rotate size",
+			config: cilog.Config{Dir: "log", Module: "module", RotateSize: -1},
+			errMsg: "cilog config: invalid rotateSize -1",
+		},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.errMsg == "" {
+			assert.NoError(t, err, tt.name)
+		} else if assert.Error(t, err, tt.name) {
+			assert.Equal(t, tt.errMsg, err.Error(), tt.name)
+		}
+	}
+}
+
+func TestConfig_Build(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "cilog.yml")
+	ioutil.WriteFile(configPath, []byte(`
+dir: `+dir+`
+module: example
+moduleVer: "1.0"
+bufferSize: 16
+minLevel: info
+sinks:
+  - dir: `+filepath.Join(dir, "copy")+`
+`), 0644)
+
+	c, err := cilog.LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log(1, cilog.DEBUG, "debug", time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	logger.Log(1, cilog.INFO, "info", time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	logger.Close()
+
+	// the file of the day of the record
+	fname := "2009-11-23_example.log"
+	for _, d := range []string{dir, filepath.Join(dir, "copy")} {
+		b, err := ioutil.ReadFile(filepath.Join(d, "2009-11", fname))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, strings.HasPrefix(string(b), "example,1.0,2009-11-23,00:00:00.000000,Information,cilog_test::"), string(b))
+		assert.True(t, strings.HasSuffix(string(b), ",,info\n"), string(b))
+	}
+
+	_, err = cilog.Config{Module: "module"}.Build()
+	assert.Error(t, err)
+}
+
+func TestRemoveOldLogs(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.SetRetention(10)
+	w.WriteWithTime([]byte("abcdef"), time.Date(2009, 10, 31, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("abcdef"), time.Date(2009, 11, 12, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("abcdef"), time.Date(2009, 11, 13, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("abcdef"), time.Date(2009, 11, 13, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("abcdef"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	w.Close()
+
+	var files []string
+	filepath.Walk(dir, func(p string, f os.FileInfo, err error) error {
+		if err == nil && f.Mode().IsRegular() {
+			files = append(files, f.Name())
+		}
+		return nil
+	})
+	assert.Equal(t, []string{"2009-11-13[1]_module.log", "2009-11-13_module.log", "2009-11-23_module.log"}, files)
+	if _, err := os.Stat(filepath.Join(dir, "2009-10")); err == nil {
+		t.Errorf("empty month dir should be removed")
+	}
+}
@@ -0,0 +1,349 @@
+package cilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Field : a key and a value written in the column between the caller and the message, "key=value"
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Fields :
+type Fields []Field
+
+// Get : the value of the last field of key
+func (fs Fields) Get(key string) (string, bool) {
+	for i := len(fs) - 1; i >= 0; i-- {
+		if fs[i].Key == key {
+			return fs[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// String : "key=value key=value", commas, spaces and new lines in keys and values are replaced with '_'
+func (fs Fields) String() string {
+	return string(fs.appendCSV(nil))
+}
+
+func (fs Fields) appendCSV(buf []byte) []byte {
+	for i, f := range fs {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendFieldString(buf, f.Key)
+		buf = append(buf, '=')
+		buf = appendFieldString(buf, f.Value)
+	}
+	return buf
+}
+
+func appendFieldString(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ',', ' ', '\n', '\r', '\t':
+			buf = append(buf, '_')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// parseContextColumn : "tid key=value key=value", the first word without '=' is the thread id,
+// other words without '=' are fields of empty key
+func parseContextColumn(s string) (tid string, fs Fields) {
+	for n, w := range strings.Fields(s) {
+		if i := strings.IndexByte(w, '='); i >= 0 {
+			fs = append(fs, Field{Key: w[:i], Value: w[i+1:]})
+		} else if n == 0 {
+			tid = w
+		} else {
+			fs = append(fs, Field{Value: w})
+		}
+	}
+	return tid, fs
+}
+
+// UnmarshalJSON : {"key":"value",...}, the order of the keys is kept
+func (fs *Fields) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		*fs = nil
+		return nil
+	}
+	if d, ok := t.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("invalid fields [%s]", data)
+	}
+	var v Fields
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := t.(string)
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		s, ok := val.(string)
+		if !ok {
+			s = fmt.Sprint(val)
+		}
+		v = append(v, Field{Key: key, Value: s})
+	}
+	*fs = v
+	return nil
+}
+
+// MarshalJSON :
+func (fs Fields) MarshalJSON() ([]byte, error) {
+	return fs.appendJSON(nil), nil
+}
+
+func (fs Fields) appendJSON(buf []byte) []byte {
+	buf = append(buf, '{')
+	for i, f := range fs {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, f.Value)
+	}
+	return append(buf, '}')
+}
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	fieldsKey
+	requestIDKey
+	traceKey
+)
+
+// orBackground : a nil ctx of the helpers of this file is context.Background()
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// NewContext : returns a context carrying l, FromContext(ctx) returns l
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(orBackground(ctx), loggerKey, l)
+}
+
+// FromContext : the logger of ctx, the standard logger if ctx has no logger
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := orBackground(ctx).Value(loggerKey).(*Logger); ok {
+		return l
+	}
+	return std
+}
+
+// WithFields : returns a context carrying the fields of ctx and fields
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	prev := FieldsFromContext(ctx)
+	fs := make(Fields, 0, len(prev)+len(fields))
+	fs = append(fs, prev...)
+	fs = append(fs, fields...)
+	return context.WithValue(orBackground(ctx), fieldsKey, fs)
+}
+
+// FieldsFromContext : the fields added with WithFields
+func FieldsFromContext(ctx context.Context) Fields {
+	fs, _ := orBackground(ctx).Value(fieldsKey).(Fields)
+	return fs
+}
+
+// WithRequestID : returns a context carrying id, written as "request_id=id"
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(orBackground(ctx), requestIDKey, id)
+}
+
+// RequestIDFromContext :
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := orBackground(ctx).Value(requestIDKey).(string)
+	return id, ok
+}
+
+type traceContext struct {
+	traceID string
+	spanID  string
+}
+
+// ParseTraceparent : parses a W3C traceparent header, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(s string) (traceID string, spanID string, err error) {
+	cols := strings.Split(strings.TrimSpace(s), "-")
+	if len(cols) < 4 || len(cols[0]) != 2 || cols[0] == "ff" || !isLowerHex(cols[0]) ||
+		len(cols[1]) != 32 || !isLowerHex(cols[1]) || strings.Trim(cols[1], "0") == "" ||
+		len(cols[2]) != 16 || !isLowerHex(cols[2]) || strings.Trim(cols[2], "0") == "" ||
+		len(cols[3]) != 2 || !isLowerHex(cols[3]) || (cols[0] == "00" && len(cols) != 4) {
+		return "", "", fmt.Errorf("invalid traceparent [%s]", s)
+	}
+	return cols[1], cols[2], nil
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// WithTrace : returns a context carrying the trace and the span, written as "trace_id=traceID span_id=spanID"
+func WithTrace(ctx context.Context, traceID string, spanID string) context.Context {
+	return context.WithValue(orBackground(ctx), traceKey, traceContext{traceID: traceID, spanID: spanID})
+}
+
+// WithTraceparent : returns a context carrying the trace and the span of a W3C traceparent header,
+// ctx is returned if the header is invalid
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	traceID, spanID, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return orBackground(ctx)
+	}
+	return WithTrace(ctx, traceID, spanID)
+}
+
+// TraceFromContext :
+func TraceFromContext(ctx context.Context) (traceID string, spanID string, ok bool) {
+	tc, ok := orBackground(ctx).Value(traceKey).(traceContext)
+	return tc.traceID, tc.spanID, ok
+}
+
+// ContextExtractor : appends fields taken from ctx to fs, e.g. values of other packages' context keys
+type ContextExtractor func(ctx context.Context, fs Fields) Fields
+
+type extractorEntry struct {
+	extract ContextExtractor
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []*extractorEntry
+)
+
+// AddContextExtractor : e is called for the records of all loggers logged with a context,
+// after the request id, the trace and the fields of WithFields are added.
+// the returned remove removes e, calling it again does nothing
+func AddContextExtractor(e ContextExtractor) (remove func()) {
+	entry := &extractorEntry{extract: e}
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors[:len(extractors):len(extractors)], entry)
+	return func() {
+		extractorsMu.Lock()
+		defer extractorsMu.Unlock()
+		// copied, a record being logged may range over the old slice
+		es := make([]*extractorEntry, 0, len(extractors))
+		for _, v := range extractors {
+			if v != entry {
+				es = append(es, v)
+			}
+		}
+		extractors = es
+	}
+}
+
+// contextFields : the fields of the records logged with ctx
+func contextFields(ctx context.Context) Fields {
+	var fs Fields
+	if id, ok := RequestIDFromContext(ctx); ok {
+		fs = append(fs, Field{Key: "request_id", Value: id})
+	}
+	if traceID, spanID, ok := TraceFromContext(ctx); ok {
+		fs = append(fs, Field{Key: "trace_id", Value: traceID}, Field{Key: "span_id", Value: spanID})
+	}
+	fs = append(fs, FieldsFromContext(ctx)...)
+
+	extractorsMu.RLock()
+	es := extractors
+	extractorsMu.RUnlock()
+	for _, e := range es {
+		fs = e.extract(ctx, fs)
+	}
+	return fs
+}
+
+// LogContext : Log with the fields of ctx
+func (l *Logger) LogContext(ctx context.Context, calldepth int, lvl Level, msg string, t time.Time) {
+	l.log(ctx, calldepth+1, lvl, text(msg), nil, nil, t, nil)
+}
+
+// TraceContext : Tracef with the logger and the fields of ctx
+func TraceContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, TRACE, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// DebugContext :
+func DebugContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, DEBUG, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// ReportContext :
+func ReportContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, REPORT, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// InfoContext :
+func InfoContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, INFO, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// NoticeContext :
+func NoticeContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, NOTICE, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// SuccessContext :
+func SuccessContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, SUCCESS, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// WarningContext :
+func WarningContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, WARNING, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// ErrorContext :
+func ErrorContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, ERROR, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// FailContext :
+func FailContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, FAIL, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// ExceptionContext :
+func ExceptionContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, EXCEPTION, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// CriticalContext :
+func CriticalContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, CRITICAL, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// AlertContext :
+func AlertContext(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).log(ctx, 2, ALERT, msgf(format), v, nil, time.Time{}, nil)
+}
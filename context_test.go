@@ -0,0 +1,144 @@
+package cilog_test
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_LogContext(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	ctx := cilog.WithRequestID(context.Background(), "req1")
+	ctx = cilog.WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx = cilog.WithFields(ctx, cilog.Field{Key: "user", Value: "a b,c"})
+	logger.LogContext(ctx, 1, cilog.INFO, "abc", time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.Local))
+	_, file, line, _ := runtime.Caller(0)
+	file = file[strings.LastIndex(file, "/")+1:]
+
+	expected := "module,1.0,2009-11-23,15:21:30.123456,Information,cilog_test::" + file + ":" + strconv.Itoa(line-1) +
+		",request_id=req1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 user=a_b_c,abc\n"
+	assert.Equal(t, expected, w.writed)
+
+	r, err := cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	v, _ := r.Fields.Get("trace_id")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", v)
+	v, _ = r.Fields.Get("user")
+	assert.Equal(t, "a_b_c", v)
+	assert.Equal(t, "abc", r.Message)
+}
+
+func TestInfoContext(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	ctx := cilog.NewContext(context.Background(), logger)
+	assert.Equal(t, logger, cilog.FromContext(ctx))
+	assert.Equal(t, cilog.StdLogger(), cilog.FromContext(context.Background()))
+
+	cilog.DebugContext(ctx, "debug")
+	assert.Equal(t, "", w.writed)
+	cilog.InfoContext(cilog.WithRequestID(ctx, "id1"), "info %d", 1)
+	_, file, line, _ := runtime.Caller(0)
+	file = file[strings.LastIndex(file, "/")+1:]
+	assert.True(t, strings.HasSuffix(w.writed, "::"+file+":"+strconv.Itoa(line-1)+",request_id=id1,info 1\n"), w.writed)
+}
+
+func TestNilContext(t *testing.T) {
+	var ctx context.Context
+	assert.Equal(t, cilog.StdLogger(), cilog.FromContext(ctx))
+	assert.Nil(t, cilog.FieldsFromContext(ctx))
+	_, ok := cilog.RequestIDFromContext(ctx)
+	assert.False(t, ok)
+	_, _, ok = cilog.TraceFromContext(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, context.Background(), cilog.WithTraceparent(ctx, "invalid"))
+
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	logger.LogContext(ctx, 1, cilog.INFO, "nil", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",nil\n"), w.writed)
+
+	w.writed = ""
+	ctx = cilog.WithFields(cilog.WithTrace(cilog.WithRequestID(cilog.NewContext(nil, logger), "id1"), "t1", "s1"), cilog.Field{Key: "user", Value: "abc"})
+	cilog.InfoContext(ctx, "info")
+	assert.True(t, strings.HasSuffix(w.writed, ",request_id=id1 trace_id=t1 span_id=s1 user=abc,info\n"), w.writed)
+	assert.Equal(t, cilog.Fields{{Key: "user", Value: "abc"}}, cilog.FieldsFromContext(cilog.WithFields(nil, cilog.Field{Key: "user", Value: "abc"})))
+}
+
+func TestAddContextExtractor(t *testing.T) {
+	type key struct{}
+	remove := cilog.AddContextExtractor(func(ctx context.Context, fs cilog.Fields) cilog.Fields {
+		if v, ok := ctx.Value(key{}).(string); ok {
+			fs = append(fs, cilog.Field{Key: "tenant", Value: v})
+		}
+		return fs
+	})
+	defer remove()
+
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.LogContext(context.WithValue(context.Background(), key{}, "t1"), 1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",tenant=t1,abc\n"), w.writed)
+
+	w.writed = ""
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",,abc\n"), w.writed)
+
+	remove()
+	remove()
+	w.writed = ""
+	logger.LogContext(context.WithValue(context.Background(), key{}, "t1"), 1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",,abc\n"), w.writed)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, err := cilog.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", spanID)
+
+	invalids := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-00",
+	}
+	for _, s := range invalids {
+		_, _, err := cilog.ParseTraceparent(s)
+		assert.Error(t, err, s)
+	}
+
+	ctx := cilog.WithTraceparent(context.Background(), "invalid")
+	_, _, ok := cilog.TraceFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func TestJSONEncoder_Fields(t *testing.T) {
+	r := cilog.Record{
+		Module:  "module",
+		Time:    time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.UTC),
+		Level:   cilog.INFO,
+		Package: "pkg",
+		File:    "a.go",
+		Line:    1,
+		Fields:  cilog.Fields{{Key: "b", Value: "1"}, {Key: "a", Value: "\"2\""}},
+		Message: "abc",
+	}
+	line := string(cilog.JSONEncoder{}.Encode(nil, &r))
+	assert.Contains(t, line, `,"fields":{"b":"1","a":"\"2\""},`)
+
+	parsed, err := cilog.ParseLine(line)
+	assert.NoError(t, err)
+	assert.Equal(t, r.Fields, parsed.Fields)
+	assert.True(t, r.Time.Equal(parsed.Time))
+}
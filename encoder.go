@@ -0,0 +1,170 @@
+package cilog
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Encoder : appends an encoded record to buf
+type Encoder interface {
+	Encode(buf []byte, r *Record) []byte
+}
+
+// CSVEncoder : the format of Logger.Log,
+// "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,18 request_id=abc,this is a example\n",
+// the caller is "package1.(*Server).Serve::src.go:56" if the record has the function, and empty if the record has no caller.
+// the thread id and the fields of the record are written as "tid key=value key=value" between the caller and the message.
+// the date and the time are a column if the layout of Time is ISO-8601 or epoch.
+// the frames of the stack are continuation lines, "\tat github.com/castisdev/cilog.Test(/src/cilog/stack_test.go:12)"
+type CSVEncoder struct {
+	Time TimeFormat
+}
+
+// Encode :
+func (e CSVEncoder) Encode(buf []byte, r *Record) []byte {
+	buf = append(buf, r.Module...)
+	buf = append(buf, ',')
+	buf = append(buf, r.ModuleVer...)
+	buf = append(buf, ',')
+	buf = e.Time.appendCSV(buf, r.Time)
+	buf = append(buf, ',')
+	buf = append(buf, r.Level.Output()...)
+	buf = append(buf, ',')
+	buf = appendCaller(buf, r)
+	buf = append(buf, ',')
+	if r.ThreadID != "" {
+		buf = appendFieldString(buf, r.ThreadID)
+		if len(r.Fields) > 0 {
+			buf = append(buf, ' ')
+		}
+	}
+	buf = r.Fields.appendCSV(buf)
+	buf = append(buf, ',')
+	buf = append(buf, r.Message...)
+	if len(r.Message) == 0 || r.Message[len(r.Message)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	for _, f := range r.Stack {
+		buf = append(buf, stackPrefix...)
+		buf = f.appendTo(buf)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// JSONEncoder : a JSON object per line,
+// {"module":"module","moduleVer":"1.0","time":"2009-11-23T15:21:30.123456+09:00","level":"debug",
+// "package":"package1","file":"src.go","line":56,"thread":"18","fields":{"request_id":"abc"},"message":"this is a example",
+// "stack":["github.com/castisdev/cilog.Test(/src/cilog/stack_test.go:12)"]}, stack is written if the record has a stack.
+// package, file and line are written if the record has a caller, and "function" after package if the record has the function
+type JSONEncoder struct {
+	Time TimeFormat
+}
+
+// Encode :
+func (e JSONEncoder) Encode(buf []byte, r *Record) []byte {
+	msg := r.Message
+	if len(msg) > 0 && msg[len(msg)-1] == '\n' {
+		msg = msg[:len(msg)-1]
+	}
+	buf = append(buf, `{"module":`...)
+	buf = appendJSONString(buf, r.Module)
+	buf = append(buf, `,"moduleVer":`...)
+	buf = appendJSONString(buf, r.ModuleVer)
+	buf = append(buf, `,"time":`...)
+	buf = e.Time.appendJSON(buf, r.Time)
+	buf = append(buf, `,"level":`...)
+	buf = appendJSONString(buf, r.Level.String())
+	if r.Package != "" || r.File != "" {
+		buf = append(buf, `,"package":`...)
+		buf = appendJSONString(buf, r.Package)
+		if r.Function != "" {
+			buf = append(buf, `,"function":`...)
+			buf = appendJSONString(buf, r.Function)
+		}
+		buf = append(buf, `,"file":`...)
+		buf = appendJSONString(buf, r.File)
+		buf = append(buf, `,"line":`...)
+		buf = strconv.AppendInt(buf, int64(r.Line), 10)
+	}
+	if r.ThreadID != "" {
+		buf = append(buf, `,"thread":`...)
+		buf = appendJSONString(buf, r.ThreadID)
+	}
+	if len(r.Fields) > 0 {
+		buf = append(buf, `,"fields":`...)
+		buf = r.Fields.appendJSON(buf)
+	}
+	buf = append(buf, `,"message":`...)
+	buf = appendJSONString(buf, msg)
+	if len(r.Stack) > 0 {
+		buf = append(buf, `,"stack":[`...)
+		for i, f := range r.Stack {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, f.String())
+		}
+		buf = append(buf, ']')
+	}
+	buf = append(buf, "}\n"...)
+	return buf
+}
+
+const hex = "0123456789abcdef"
+
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// EncoderFromString : "csv" or "json"
+func EncoderFromString(s string) (Encoder, bool) {
+	return encoderFromString(s, TimeFormat{})
+}
+
+func encoderFromString(s string, tf TimeFormat) (Encoder, bool) {
+	switch s {
+	case "", "csv":
+		return CSVEncoder{Time: tf}, true
+	case "json":
+		return JSONEncoder{Time: tf}, true
+	}
+	return nil, false
+}
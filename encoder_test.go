@@ -0,0 +1,64 @@
+package cilog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVEncoder_Encode(t *testing.T) {
+	r := cilog.Record{
+		Module:    "module",
+		ModuleVer: "1.0",
+		Time:      time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.Local),
+		Level:     cilog.DEBUG,
+		Package:   "package1",
+		File:      "src.go",
+		Line:      56,
+		Message:   "this is a example",
+	}
+	assert.Equal(t, "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,,this is a example\n",
+		string(cilog.CSVEncoder{}.Encode(nil, &r)))
+	r.Message = "ends with new line\n"
+	assert.Equal(t, "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,,ends with new line\n",
+		string(cilog.CSVEncoder{}.Encode([]byte{}, &r)))
+}
+
+func TestJSONEncoder_Encode(t *testing.T) {
+	loc := time.FixedZone("KST", 9*60*60)
+	r := cilog.Record{
+		Module:    "module",
+		ModuleVer: "1.0",
+		Time:      time.Date(2009, 11, 23, 15, 21, 30, 123456000, loc),
+		Level:     cilog.WARNING,
+		Package:   "package1",
+		File:      "src.go",
+		Line:      56,
+		Message:   "\"quoted\"\tand\\ \x01 한글 \xff\n",
+	}
+	out := cilog.JSONEncoder{}.Encode(nil, &r)
+	assert.Equal(t, `{"module":"module","moduleVer":"1.0","time":"2009-11-23T15:21:30.123456+09:00","level":"warning",`+
+		`"package":"package1","file":"src.go","line":56,"message":"\"quoted\"\tand\\ \u0001 한글 �"}`+"\n", string(out))
+
+	parsed, err := cilog.ParseLine(string(out))
+	assert.NoError(t, err)
+	assert.True(t, r.Time.Equal(parsed.Time))
+	parsed.Time = r.Time
+	r.Message = "\"quoted\"\tand\\ \x01 한글 �"
+	assert.Equal(t, r, parsed)
+}
+
+func TestLogger_SetEncoder(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetEncoder(cilog.JSONEncoder{})
+	logger.Log(1, cilog.INFO, "abc", time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.UTC))
+
+	r, err := cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	assert.Equal(t, cilog.INFO, r.Level)
+	assert.Equal(t, "encoder_test.go", r.File)
+	assert.Equal(t, "abc", r.Message)
+}
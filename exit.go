@@ -0,0 +1,87 @@
+package cilog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	exitMu    sync.Mutex
+	exitFunc  = os.Exit
+	exitHooks []func()
+)
+
+// SetExitFunc : the function called by Fatalf and RecoverAndExit after the exit hooks, os.Exit if f is nil,
+// e.g. a function which records the code in tests
+func SetExitFunc(f func(code int)) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	if f == nil {
+		f = os.Exit
+	}
+	exitFunc = f
+}
+
+// AddExitHook : f is called before Fatalf and RecoverAndExit exit or Panicf panics, in the order added
+func AddExitHook(f func()) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitHooks = append(exitHooks, f)
+}
+
+// runExitHooks : a panic of a hook is ignored so that the other hooks are called
+func runExitHooks() {
+	exitMu.Lock()
+	hooks := exitHooks
+	exitMu.Unlock()
+	for _, f := range hooks {
+		func() {
+			defer func() { recover() }()
+			f()
+		}()
+	}
+}
+
+// exit : runs the exit hooks and calls the exit function
+func exit(code int) {
+	runExitHooks()
+	exitMu.Lock()
+	f := exitFunc
+	exitMu.Unlock()
+	f(code)
+}
+
+// Fatalf : logs at CRITICAL, flushes the logger so that the record is written to the file even in the async mode,
+// runs the exit hooks and exits with 1
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.log(nil, 2, CRITICAL, msgf(format), v, nil, time.Time{}, nil)
+	l.Flush()
+	exit(1)
+}
+
+// Panicf : logs at CRITICAL, flushes the logger, runs the exit hooks and panics with the message
+func (l *Logger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.log(nil, 2, CRITICAL, text(msg), nil, nil, time.Time{}, nil)
+	l.Flush()
+	runExitHooks()
+	panic(msg)
+}
+
+// Fatalf :
+func Fatalf(format string, v ...interface{}) {
+	std.log(nil, 2, CRITICAL, msgf(format), v, nil, time.Time{}, nil)
+	std.Flush()
+	exit(1)
+}
+
+// Panicf :
+func Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	std.log(nil, 2, CRITICAL, text(msg), nil, nil, time.Time{}, nil)
+	std.Flush()
+	runExitHooks()
+	panic(msg)
+}
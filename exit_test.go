@@ -0,0 +1,97 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Fatalf(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 1024*1024)
+	w.StartWithBufferSize(16)
+	defer w.Stop()
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+
+	var calls []string
+	cilog.AddExitHook(func() { calls = append(calls, "hook1") })
+	cilog.AddExitHook(func() { panic("hook2") })
+	cilog.AddExitHook(func() { calls = append(calls, "hook3") })
+	var written string
+	cilog.SetExitFunc(func(code int) {
+		calls = append(calls, "exit")
+		assert.Equal(t, 1, code)
+		// the record is written before exit in the async mode
+		b, _ := ioutil.ReadFile(filepath.Join(dir, "module.log"))
+		written = string(b)
+	})
+	defer cilog.SetExitFunc(nil)
+
+	logger.Fatalf("fatal %d", 1)
+	_, _, line, _ := runtime.Caller(0)
+	assert.Equal(t, []string{"hook1", "hook3", "exit"}, calls)
+	assert.True(t, strings.HasSuffix(written, ",Critical,cilog_test::exit_test.go:"+strconv.Itoa(line-1)+",,fatal 1\n"), written)
+}
+
+func TestLogger_Panicf(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	hooked := false
+	cilog.AddExitHook(func() { hooked = true })
+	exited := false
+	cilog.SetExitFunc(func(code int) { exited = true })
+	defer cilog.SetExitFunc(nil)
+
+	var v interface{}
+	func() {
+		defer func() { v = recover() }()
+		logger.Panicf("panic %s", "a")
+	}()
+	assert.Equal(t, "panic a", v)
+	assert.True(t, hooked)
+	assert.False(t, exited)
+	assert.True(t, strings.HasSuffix(w.writed, ",,panic a\n"), w.writed)
+}
+
+func TestLogger_RecoverAndExit(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	code := 0
+	cilog.SetExitFunc(func(c int) { code = c })
+	defer cilog.SetExitFunc(nil)
+
+	func() {
+		defer logger.RecoverAndExit(3)
+		panic("boom")
+	}()
+	assert.Equal(t, 3, code)
+	assert.Contains(t, w.writed, ",,panic: boom\n")
+}
+
+func TestFatalf(t *testing.T) {
+	w := &stringWriter{}
+	prev := cilog.GetWriter()
+	cilog.SetWriter(w)
+	defer cilog.SetWriter(prev)
+	code := 0
+	cilog.SetExitFunc(func(c int) { code = c })
+	defer cilog.SetExitFunc(nil)
+
+	cilog.Fatalf("fatal")
+	_, _, line, _ := runtime.Caller(0)
+	assert.Equal(t, 1, code)
+	assert.True(t, strings.HasSuffix(w.writed, ",Critical,cilog_test::exit_test.go:"+strconv.Itoa(line-1)+",,fatal\n"), w.writed)
+}
@@ -0,0 +1,31 @@
+package cilog
+
+import "time"
+
+// UnregisterLevel : removes a level added by RegisterLevel, so that the tests do not leave it in the registry
+func UnregisterLevel(lvl Level) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	old := loadLevels()
+	t := &levelTable{names: map[Level]levelName{}, levels: map[string]Level{}}
+	for k, v := range old.names {
+		if k != lvl {
+			t.names[k] = v
+		}
+	}
+	for k, v := range old.levels {
+		if v != lvl {
+			t.levels[k] = v
+		}
+	}
+	levels.Store(t)
+}
+
+// SetLevelAfter : SetLevel holding the lock of the handler for wait before setting the level,
+// so that a revert fired in the meantime waits for the lock
+func SetLevelAfter(h *AdminHandler, wait time.Duration, pkg string, lvl Level, ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	time.Sleep(wait)
+	h.setLevel(pkg, lvl, ttl)
+}
@@ -0,0 +1,16 @@
+package cilog
+
+import (
+	"os"
+	"syscall"
+)
+
+const flockSupported = true
+
+func flock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func funlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
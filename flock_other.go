@@ -0,0 +1,17 @@
+//go:build !linux
+
+package cilog
+
+import (
+	"os"
+)
+
+const flockSupported = false
+
+func flock(f *os.File) error {
+	return ErrProcessLockNotSupported
+}
+
+func funlock(f *os.File) error {
+	return ErrProcessLockNotSupported
+}
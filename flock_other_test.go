@@ -0,0 +1,16 @@
+//go:build !linux
+
+package cilog_test
+
+import (
+	"testing"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogWriter_ProcessLockNotSupported(t *testing.T) {
+	w := cilog.NewLogWriter("ut.dir", "module", 1024)
+	assert.Equal(t, cilog.ErrProcessLockNotSupported, w.SetProcessLock(true))
+	assert.NoError(t, w.SetProcessLock(false))
+}
@@ -0,0 +1,74 @@
+package cilog
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	buildInfoOnce sync.Once
+	buildInfo     string
+)
+
+// buildInfoString : "path@version(revision)" of the main module
+func buildInfoString() string {
+	buildInfoOnce.Do(func() {
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			buildInfo = "unknown"
+			return
+		}
+		buildInfo = bi.Main.Path + "@" + bi.Main.Version
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				buildInfo += "(" + s.Value + ")"
+			}
+		}
+	})
+	return buildInfo
+}
+
+// SetHeaderEnabled : if enabled, a header record is written at the start of every new file
+// and a footer record when the file is closed
+func (w *LogWriter) SetHeaderEnabled(enabled bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.header = enabled
+}
+
+// SetModuleVer : sets the module version written in header and footer records
+func (w *LogWriter) SetModuleVer(v string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.moduleVer = v
+}
+
+func (w *LogWriter) headerRecord(t time.Time) []byte {
+	host, _ := os.Hostname()
+	msg := fmt.Sprintf("log file header, host=%s pid=%d go=%s build=%s start=%s prev=%s",
+		host, os.Getpid(), runtime.Version(), buildInfoString(), t.Format(time.RFC3339Nano), w.prevPath)
+	return w.headerFooterRecord(t, msg)
+}
+
+func (w *LogWriter) footerRecord(t time.Time, reason CloseReason) []byte {
+	msg := fmt.Sprintf("log file footer, reason=%s bytes=%d lines=%d end=%s",
+		reason, w.fileBytes, w.fileLines, t.Format(time.RFC3339Nano))
+	return w.headerFooterRecord(t, msg)
+}
+
+func (w *LogWriter) headerFooterRecord(t time.Time, msg string) []byte {
+	r := Record{
+		Module:    w.module,
+		ModuleVer: w.moduleVer,
+		Time:      t,
+		Level:     INFO,
+		Package:   "cilog",
+		File:      "header.go",
+		Message:   msg,
+	}
+	return CSVEncoder{}.Encode(nil, &r)
+}
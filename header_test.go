@@ -0,0 +1,57 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogWriter_Header(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 300)
+	w.SetHeaderEnabled(true)
+	w.SetModuleVer("1.0")
+	w.WriteWithTime([]byte("abc\n"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte(strings.Repeat("x", 300)+"\n"), time.Date(2009, 11, 23, 1, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("def\n"), time.Date(2009, 11, 23, 2, 0, 0, 0, time.Local))
+	w.Close()
+
+	first := filepath.Join(dir, "2009-11", "2009-11-23_module.log")
+	b1, err := ioutil.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b1), "\n"), "\n")
+	assert.Equal(t, 4, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0],
+		"module,1.0,2009-11-23,00:00:00.000000,Information,cilog::header.go:0,,log file header, host="), lines[0])
+	assert.Contains(t, lines[0], "pid="+strconv.Itoa(os.Getpid()))
+	assert.True(t, strings.HasSuffix(lines[0], "prev="), lines[0])
+	assert.Equal(t, "abc", lines[1])
+	assert.Equal(t, strings.Repeat("x", 300), lines[2])
+	assert.Contains(t, lines[3], "log file footer, reason=size")
+
+	b2, err := ioutil.ReadFile(filepath.Join(dir, "2009-11", "2009-11-23[1]_module.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSuffix(string(b2), "\n"), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[0], "prev="+first)
+	assert.Equal(t, "def", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2],
+		"module,1.0,2009-11-23,02:00:00.000000,Information,cilog::header.go:0,,log file footer, reason=shutdown"), lines[2])
+}
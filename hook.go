@@ -0,0 +1,106 @@
+package cilog
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// CloseReason :
+type CloseReason int
+
+// CloseReason enum
+const (
+	closeByRemove = CloseReason(iota)
+	CloseBySize
+	CloseByDay
+	CloseByReopen
+	CloseByShutdown
+	CloseByRotate
+)
+
+// String :
+func (r CloseReason) String() string {
+	switch r {
+	case CloseBySize:
+		return "size"
+	case CloseByDay:
+		return "day"
+	case CloseByReopen:
+		return "reopen"
+	case CloseByShutdown:
+		return "shutdown"
+	case CloseByRotate:
+		return "rotate"
+	default:
+		return ""
+	}
+}
+
+// ClosedFile : a log file closed by LogWriter.
+// Start, End, Bytes and Lines cover what the LogWriter has written since it opened the file.
+type ClosedFile struct {
+	Path   string
+	Start  time.Time
+	End    time.Time
+	Bytes  int64
+	Lines  int64
+	Reason CloseReason
+}
+
+// AddCloseHook : adds f called with every closed file.
+// hooks are called in a separate goroutine, not in the write path.
+func (w *LogWriter) AddCloseHook(f func(ClosedFile)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.closeHooks = append(w.closeHooks, f)
+}
+
+// SetCloseCommand : sets the command run with every closed file, the path of the file is appended to args.
+// CILOG_PATH, CILOG_REASON, CILOG_BYTES and CILOG_LINES are added to its environment.
+func (w *LogWriter) SetCloseCommand(name string, args ...string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if name == "" {
+		w.closeCmd = nil
+		return
+	}
+	w.closeCmd = append([]string{name}, args...)
+}
+
+// notifyClose : w.lock must be held
+func (w *LogWriter) notifyClose(f ClosedFile) {
+	if len(w.closeHooks) == 0 && len(w.closeCmd) == 0 {
+		return
+	}
+	hooks := append([]func(ClosedFile){}, w.closeHooks...)
+	cmd := w.closeCmd
+
+	w.hookWG.Add(1)
+	go func() {
+		defer w.hookWG.Done()
+		for _, h := range hooks {
+			h(f)
+		}
+		if len(cmd) > 0 {
+			if err := runCloseCommand(cmd, f); err != nil {
+				w.handleError(err)
+			}
+		}
+	}()
+}
+
+func runCloseCommand(cmd []string, f ClosedFile) error {
+	c := exec.Command(cmd[0], append(cmd[1:], f.Path)...)
+	c.Env = append(os.Environ(),
+		"CILOG_PATH="+f.Path,
+		"CILOG_REASON="+f.Reason.String(),
+		fmt.Sprintf("CILOG_BYTES=%d", f.Bytes),
+		fmt.Sprintf("CILOG_LINES=%d", f.Lines),
+	)
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("close command %v failed, %v, %s", cmd, err, out)
+	}
+	return nil
+}
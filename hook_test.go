@@ -0,0 +1,119 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogWriter_CloseHook(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	closed := map[string]cilog.ClosedFile{}
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.AddCloseHook(func(f cilog.ClosedFile) {
+		mu.Lock()
+		defer mu.Unlock()
+		closed[filepath.Base(f.Path)] = f
+	})
+
+	t1 := time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local)
+	t2 := time.Date(2009, 11, 23, 1, 0, 0, 0, time.Local)
+	t3 := time.Date(2009, 11, 24, 0, 0, 0, 0, time.Local)
+	w.WriteWithTime([]byte("ab\n"), t1)
+	w.WriteWithTime([]byte("cd\n"), t2)
+	w.WriteWithTime([]byte("ef\n"), t2)
+	w.WriteWithTime([]byte("gh\n"), t3)
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, len(closed))
+	assert.Equal(t, cilog.ClosedFile{
+		Path:   filepath.Join(dir, "2009-11", "2009-11-23_module.log"),
+		Start:  t1,
+		End:    t2,
+		Bytes:  6,
+		Lines:  2,
+		Reason: cilog.CloseBySize,
+	}, closed["2009-11-23_module.log"])
+	assert.Equal(t, cilog.CloseByDay, closed["2009-11-23[1]_module.log"].Reason)
+	assert.Equal(t, cilog.CloseByShutdown, closed["2009-11-24_module.log"].Reason)
+	assert.Equal(t, int64(1), closed["2009-11-24_module.log"].Lines)
+}
+
+func TestLogWriter_CloseHookReopen(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	reasons := make(chan cilog.CloseReason, 1)
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.AddCloseHook(func(f cilog.ClosedFile) {
+		reasons <- f.Reason
+	})
+	w.Write([]byte("abc"))
+	w.Reopen()
+
+	select {
+	case r := <-reasons:
+		assert.Equal(t, cilog.CloseByReopen, r)
+	case <-time.After(time.Second):
+		t.Error("close hook is not called")
+	}
+}
+
+func TestLogWriter_CloseCommand(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "uploaded")
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.SetCloseCommand("sh", "-c", `echo -n "$CILOG_REASON " > `+dst+` && cat "$0" >> `+dst)
+	w.WriteWithTime([]byte("abc"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	w.Close()
+
+	b, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "shutdown abc" {
+		t.Errorf("command output expected %s, but %s", "shutdown abc", string(b))
+	}
+}
+
+func TestLogWriter_CloseCommandError(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	errs := make(chan error, 1)
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.SetErrorHandler(func(err error) { errs <- err })
+	w.SetCloseCommand("false")
+	w.WriteWithTime([]byte("abc"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	w.Close()
+
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	default:
+		t.Error("command error is not reported")
+	}
+}
@@ -0,0 +1,105 @@
+package cilog
+
+import "time"
+
+// Enabled : whether a record of lvl logged by the caller is written, with the package levels of the caller.
+// e.g. "if l.Enabled(cilog.DEBUG) { ... }" skips preparing expensive arguments, and boxing the arguments of Debugf
+// which allocates even for filtered records
+func (l *Logger) Enabled(lvl Level) bool {
+	return l.enabled(2, lvl)
+}
+
+// enabled : calldepth is the depth of the caller from enabled
+func (l *Logger) enabled(calldepth int, lvl Level) bool {
+	l.mu.RLock()
+	minLevel, lowest, pkgLevels, pkgGen, skip := l.minLevel, l.lowestLevel, l.pkgLevels, l.pkgGen, l.caller.skip
+	l.mu.RUnlock()
+	if lowest.Rank() > lvl.Rank() {
+		return false
+	}
+	if len(pkgLevels) == 0 {
+		return minLevel.Rank() <= lvl.Rank()
+	}
+	pkgLevel := l.packageLevelAt(callerPC(calldepth+skip), pkgLevels, pkgGen)
+	if pkgLevel == 0 {
+		pkgLevel = minLevel
+	}
+	return pkgLevel.Rank() <= lvl.Rank()
+}
+
+// Logf : Log with the message of format, which is formatted only if the record is written.
+// the arguments are boxed by the caller even if the record is filtered, which allocates for non-constant values,
+// check Enabled or use LogFunc in hot loops
+func (l *Logger) Logf(calldepth int, lvl Level, t time.Time, format string, v ...interface{}) {
+	l.log(nil, calldepth+1, lvl, msgf(format), v, nil, t, nil)
+}
+
+// LogFunc : Log with the message of f, f is called only if the record is written
+func (l *Logger) LogFunc(calldepth int, lvl Level, f func() string, t time.Time) {
+	l.log(nil, calldepth+1, lvl, message{}, nil, f, t, nil)
+}
+
+// Enabled :
+func Enabled(lvl Level) bool {
+	return std.enabled(2, lvl)
+}
+
+// TraceFunc : Tracef with the message of f, f is called only if the record is written
+func TraceFunc(f func() string) {
+	std.log(nil, 2, TRACE, message{}, nil, f, time.Time{}, nil)
+}
+
+// DebugFunc :
+func DebugFunc(f func() string) {
+	std.log(nil, 2, DEBUG, message{}, nil, f, time.Time{}, nil)
+}
+
+// ReportFunc :
+func ReportFunc(f func() string) {
+	std.log(nil, 2, REPORT, message{}, nil, f, time.Time{}, nil)
+}
+
+// InfoFunc :
+func InfoFunc(f func() string) {
+	std.log(nil, 2, INFO, message{}, nil, f, time.Time{}, nil)
+}
+
+// NoticeFunc :
+func NoticeFunc(f func() string) {
+	std.log(nil, 2, NOTICE, message{}, nil, f, time.Time{}, nil)
+}
+
+// SuccessFunc :
+func SuccessFunc(f func() string) {
+	std.log(nil, 2, SUCCESS, message{}, nil, f, time.Time{}, nil)
+}
+
+// WarningFunc :
+func WarningFunc(f func() string) {
+	std.log(nil, 2, WARNING, message{}, nil, f, time.Time{}, nil)
+}
+
+// ErrorFunc :
+func ErrorFunc(f func() string) {
+	std.log(nil, 2, ERROR, message{}, nil, f, time.Time{}, nil)
+}
+
+// FailFunc :
+func FailFunc(f func() string) {
+	std.log(nil, 2, FAIL, message{}, nil, f, time.Time{}, nil)
+}
+
+// ExceptionFunc :
+func ExceptionFunc(f func() string) {
+	std.log(nil, 2, EXCEPTION, message{}, nil, f, time.Time{}, nil)
+}
+
+// CriticalFunc :
+func CriticalFunc(f func() string) {
+	std.log(nil, 2, CRITICAL, message{}, nil, f, time.Time{}, nil)
+}
+
+// AlertFunc :
+func AlertFunc(f func() string) {
+	std.log(nil, 2, ALERT, message{}, nil, f, time.Time{}, nil)
+}
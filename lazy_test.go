@@ -0,0 +1,140 @@
+package cilog_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Enabled(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.INFO)
+	assert.False(t, logger.Enabled(cilog.DEBUG))
+	assert.True(t, logger.Enabled(cilog.INFO))
+	assert.True(t, logger.Enabled(cilog.ERROR))
+
+	logger.SetPackageLevel("cilog_test", cilog.TRACE)
+	assert.True(t, logger.Enabled(cilog.TRACE))
+	logger.SetPackageLevel("cilog_test", cilog.ERROR)
+	assert.False(t, logger.Enabled(cilog.INFO))
+	assert.True(t, logger.Enabled(cilog.ERROR))
+}
+
+func TestLogger_LogFunc(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	called := 0
+	f := func() string {
+		called++
+		return "lazy"
+	}
+	logger.LogFunc(1, cilog.DEBUG, f, time.Now())
+	assert.Equal(t, 0, called)
+	assert.Equal(t, "", w.writed)
+	logger.LogFunc(1, cilog.INFO, f, time.Now())
+	assert.Equal(t, 1, called)
+	assert.True(t, strings.HasSuffix(w.writed, ",,lazy\n"), w.writed)
+
+	w.writed = ""
+	logger.Logf(1, cilog.INFO, time.Now(), "%d%%", 100)
+	assert.True(t, strings.HasSuffix(w.writed, ",,100%\n"), w.writed)
+}
+
+func TestDebugf_Filtered(t *testing.T) {
+	w := &stringWriter{}
+	prev := cilog.GetWriter()
+	prevLevel := cilog.GetMinLevel()
+	cilog.SetWriter(w)
+	cilog.SetMinLevel(cilog.INFO)
+	defer func() {
+		cilog.SetWriter(prev)
+		cilog.SetMinLevel(prevLevel)
+	}()
+
+	cilog.Infof("100%%")
+	assert.True(t, strings.HasSuffix(w.writed, ",,100%\n"), w.writed)
+	w.writed = ""
+	cilog.InfoFunc(func() string { return "lazy" })
+	assert.True(t, strings.HasSuffix(w.writed, ",,lazy\n"), w.writed)
+
+	// filtered records do not format
+	s, n := "abc", 1
+	arg := &countingStringer{}
+	cilog.Debugf("%s %d %v", s, n, arg)
+	assert.Equal(t, 0, arg.called)
+
+	// guarded by Enabled or lazy, filtered records do not allocate even with non-constant arguments
+	allocs := testing.AllocsPerRun(100, func() {
+		if cilog.Enabled(cilog.DEBUG) {
+			cilog.Debugf("%s %d", s, n)
+		}
+		cilog.DebugFunc(func() string { return fmt.Sprintf("%s %d", s, n) })
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+type countingStringer struct {
+	called int
+}
+
+func (c *countingStringer) String() string {
+	c.called++
+	return "counted"
+}
+
+func BenchmarkDebugf_Filtered(b *testing.B) {
+	prev := cilog.GetWriter()
+	prevLevel := cilog.GetMinLevel()
+	cilog.SetWriter(dummyWriter{})
+	cilog.SetMinLevel(cilog.INFO)
+	defer func() {
+		cilog.SetWriter(prev)
+		cilog.SetMinLevel(prevLevel)
+	}()
+	s := "abc"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// the non-constant arguments are boxed even if the record is filtered
+		cilog.Debugf("%s %d", s, i)
+	}
+}
+
+func BenchmarkDebugf_FilteredEnabled(b *testing.B) {
+	prevLevel := cilog.GetMinLevel()
+	cilog.SetMinLevel(cilog.INFO)
+	defer cilog.SetMinLevel(prevLevel)
+	s := "abc"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if cilog.Enabled(cilog.DEBUG) {
+			cilog.Debugf("%s %d", s, i)
+		}
+	}
+}
+
+func BenchmarkDebugFunc_Filtered(b *testing.B) {
+	prevLevel := cilog.GetMinLevel()
+	cilog.SetMinLevel(cilog.INFO)
+	defer cilog.SetMinLevel(prevLevel)
+	s := "abc"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cilog.DebugFunc(func() string { return fmt.Sprintf("%s %d", s, i) })
+	}
+}
+
+func BenchmarkLogger_Enabled(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if logger.Enabled(cilog.DEBUG) {
+			b.Fatal("enabled")
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package cilog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Level :
+type Level int
+
+// Level enum
+const (
+	_           = iota
+	DEBUG Level = iota
+	REPORT
+	INFO
+	SUCCESS
+	WARNING
+	ERROR
+	FAIL
+	EXCEPTION
+	CRITICAL
+)
+
+// LevelRankBase : the levels above LevelRankBase are ordered by their value minus LevelRankBase,
+// among the levels from DEBUG to CRITICAL ranked 10 to 90, e.g. LevelRankBase+45 is between SUCCESS and WARNING
+const LevelRankBase Level = 100
+
+// levels ordered by Rank among the levels of the enum
+const (
+	TRACE  = LevelRankBase + 5
+	NOTICE = LevelRankBase + 35
+	ALERT  = LevelRankBase + 95
+)
+
+// Rank : the order of the level, a higher rank is more severe, DEBUG is 10, INFO is 30 and CRITICAL is 90.
+// levels must be compared by Rank, not by their values. 0 if the level is not set or invalid
+func (l Level) Rank() int {
+	switch {
+	case l >= DEBUG && l <= CRITICAL:
+		return int(l) * 10
+	case l > LevelRankBase:
+		return int(l - LevelRankBase)
+	}
+	return 0
+}
+
+type levelName struct {
+	name   string
+	output string
+}
+
+// levelTable : replaced as a whole by RegisterLevel, never modified
+type levelTable struct {
+	names  map[Level]levelName
+	levels map[string]Level
+}
+
+var (
+	levelMu sync.Mutex
+	levels  atomic.Value // *levelTable
+)
+
+func init() {
+	t := &levelTable{names: map[Level]levelName{}, levels: map[string]Level{}}
+	for _, v := range []struct {
+		lvl    Level
+		name   string
+		output string
+	}{
+		{TRACE, "trace", "Trace"},
+		{DEBUG, "debug", "Debug"},
+		{REPORT, "report", "Report"},
+		{INFO, "info", "Information"},
+		{NOTICE, "notice", "Notice"},
+		{SUCCESS, "success", "Success"},
+		{WARNING, "warning", "Warning"},
+		{ERROR, "error", "Error"},
+		{FAIL, "fail", "Fail"},
+		{EXCEPTION, "exception", "Exception"},
+		{CRITICAL, "critical", "Critical"},
+		{ALERT, "alert", "Alert"},
+	} {
+		t.add(v.lvl, v.name, v.output)
+	}
+	t.levels["warn"] = WARNING
+	t.levels["err"] = ERROR
+	t.levels["fatal"] = CRITICAL
+	levels.Store(t)
+}
+
+func (t *levelTable) add(lvl Level, name string, output string) {
+	t.names[lvl] = levelName{name: name, output: output}
+	t.levels[strings.ToLower(name)] = lvl
+	t.levels[strings.ToLower(output)] = lvl
+}
+
+func loadLevels() *levelTable {
+	return levels.Load().(*levelTable)
+}
+
+// RegisterLevel : adds a level above LevelRankBase, its Rank orders it among the other levels
+// (e.g. LevelRankBase+45 is between SUCCESS and WARNING), name is used by String and output by Output.
+// both names are case insensitive for LevelFromString.
+func RegisterLevel(lvl Level, name string, output string) error {
+	if lvl <= LevelRankBase {
+		return fmt.Errorf("invalid log level, %d", int(lvl))
+	}
+	for _, s := range []string{name, output} {
+		if s == "" || strings.ContainsAny(s, ", \t\r\n") {
+			return fmt.Errorf("invalid level name [%s]", s)
+		}
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	old := loadLevels()
+	for l, n := range old.names {
+		if l.Rank() == lvl.Rank() {
+			return fmt.Errorf("log level %d is already registered as %s", int(lvl), n.name)
+		}
+	}
+	for _, s := range []string{name, output} {
+		if _, ok := old.levels[strings.ToLower(s)]; ok {
+			return fmt.Errorf("level name [%s] is already registered", s)
+		}
+	}
+
+	t := &levelTable{names: map[Level]levelName{}, levels: map[string]Level{}}
+	for k, v := range old.names {
+		t.names[k] = v
+	}
+	for k, v := range old.levels {
+		t.levels[k] = v
+	}
+	t.add(lvl, name, output)
+	levels.Store(t)
+	return nil
+}
+
+// Output :
+func (l Level) Output() string {
+	return loadLevels().names[l].output
+}
+
+// String :
+func (l Level) String() string {
+	return loadLevels().names[l].name
+}
+
+// UnmarshalYAML :
+func (l *Level) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	var err error
+	if err = unmarshal(&s); err != nil {
+		return err
+	}
+	if *l, err = LevelFromString(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalYAML : the unset level 0 is an empty string, which is not unmarshaled, Config omits it
+func (l Level) MarshalYAML() (interface{}, error) {
+	if l == 0 {
+		return "", nil
+	}
+	str := l.String()
+	if str == "" {
+		return "", fmt.Errorf("invalid log level, %d", int(l))
+	}
+	return str, nil
+}
+
+// UnmarshalJSON :
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	var err error
+	if err = json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if *l, err = LevelFromString(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalJSON : the unset level 0 is an empty string, which is not unmarshaled, Config omits it
+func (l Level) MarshalJSON() ([]byte, error) {
+	if l == 0 {
+		return []byte(`""`), nil
+	}
+	str := l.String()
+	if str == "" {
+		return nil, fmt.Errorf("invalid log level, %d", int(l))
+	}
+	return json.Marshal(str)
+}
+
+// UnmarshalText :
+func (l *Level) UnmarshalText(text []byte) error {
+	var err error
+	*l, err = LevelFromString(string(text))
+	return err
+}
+
+// MarshalText : the unset level 0 is an empty text, which is not unmarshaled
+func (l Level) MarshalText() ([]byte, error) {
+	if l == 0 {
+		return []byte{}, nil
+	}
+	str := l.String()
+	if str == "" {
+		return nil, fmt.Errorf("invalid log level, %d", int(l))
+	}
+	return []byte(str), nil
+}
+
+// Set : flag.Value
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// LevelFromString : case insensitive, accepts the names of String and Output and some aliases ("warn", "err", "fatal")
+func LevelFromString(s string) (Level, error) {
+	v, ok := loadLevels().levels[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return DEBUG, fmt.Errorf("invalid level string [%s]", s)
+	}
+	return v, nil
+}
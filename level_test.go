@@ -0,0 +1,72 @@
+package cilog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestLevel_Order(t *testing.T) {
+	ordered := []cilog.Level{
+		cilog.TRACE, cilog.DEBUG, cilog.REPORT, cilog.INFO, cilog.NOTICE, cilog.SUCCESS,
+		cilog.WARNING, cilog.ERROR, cilog.FAIL, cilog.EXCEPTION, cilog.CRITICAL, cilog.ALERT,
+	}
+	for i := 1; i < len(ordered); i++ {
+		assert.True(t, ordered[i-1].Rank() < ordered[i].Rank(), "%s < %s", ordered[i-1], ordered[i])
+	}
+	// the values of the original levels are kept
+	assert.Equal(t, cilog.Level(1), cilog.DEBUG)
+	assert.Equal(t, cilog.Level(3), cilog.INFO)
+	assert.Equal(t, cilog.Level(9), cilog.CRITICAL)
+	assert.Equal(t, "trace", cilog.TRACE.String())
+	assert.Equal(t, "Trace", cilog.TRACE.Output())
+	assert.Equal(t, "Notice", cilog.NOTICE.Output())
+	assert.Equal(t, "Alert", cilog.ALERT.Output())
+	assert.Equal(t, "", cilog.Level(0).String())
+	assert.Equal(t, 0, cilog.Level(0).Rank())
+}
+
+func TestRegisterLevel(t *testing.T) {
+	const AUDIT = cilog.LevelRankBase + 45
+	if !assert.NoError(t, cilog.RegisterLevel(AUDIT, "audit", "Audit")) {
+		return
+	}
+	defer cilog.UnregisterLevel(AUDIT)
+	assert.True(t, cilog.SUCCESS.Rank() < AUDIT.Rank() && AUDIT.Rank() < cilog.WARNING.Rank())
+	assert.Equal(t, "audit", AUDIT.String())
+	assert.Equal(t, "Audit", AUDIT.Output())
+	lvl, err := cilog.LevelFromString("AUDIT")
+	assert.NoError(t, err)
+	assert.Equal(t, AUDIT, lvl)
+
+	assert.Error(t, cilog.RegisterLevel(AUDIT, "audit2", "Audit2"))
+	assert.Error(t, cilog.RegisterLevel(cilog.LevelRankBase+46, "Audit", "Audit3"))
+	assert.Error(t, cilog.RegisterLevel(cilog.LevelRankBase+46, "info", "Info2"))
+	assert.Error(t, cilog.RegisterLevel(cilog.LevelRankBase+46, "a,b", "AB"))
+	assert.Error(t, cilog.RegisterLevel(cilog.Level(0), "zero", "Zero"))
+	// the values below LevelRankBase and the ranks of the other levels are taken
+	assert.Error(t, cilog.RegisterLevel(cilog.Level(46), "audit46", "Audit46"))
+	assert.Error(t, cilog.RegisterLevel(cilog.LevelRankBase+30, "info2", "Info2"))
+
+	var v struct {
+		Level cilog.Level `yaml:"level"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte("level: audit"), &v))
+	assert.Equal(t, AUDIT, v.Level)
+	out, err := yaml.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, "level: audit\n", string(out))
+
+	// filtering
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", AUDIT)
+	logger.Log(1, cilog.SUCCESS, "abc", time.Now())
+	assert.Equal(t, "", w.writed)
+	logger.Log(1, AUDIT, "abc", time.Now())
+	r, err := cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	assert.Equal(t, AUDIT, r.Level)
+}
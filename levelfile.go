@@ -0,0 +1,107 @@
+package cilog
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// levelFile : a LogWriter of module_suffix taking records of minLevel or above
+type levelFile struct {
+	minLevel Level
+	w        *LogWriter
+}
+
+// AddLevelFile : logs of minLevel or above written with WriteLevel are also written to "module_suffix.log" files,
+// e.g. AddLevelFile("error", ERROR) makes "2009-11-23_module_error.log" and its link "module_error.log".
+// the returned LogWriter has the same settings as w at this time, and can be configured separately.
+func (w *LogWriter) AddLevelFile(suffix string, minLevel Level) *LogWriter {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	lw := NewLogWriter(w.dir, w.module+"_"+suffix, w.rotateSize)
+	lw.copyTruncate = w.copyTruncate
+	lw.processLock = w.processLock
+	lw.noLink = w.noLink
+	lw.linkRelative = w.linkRelative
+	lw.linkDir = w.linkDir
+	if w.linkName != "" {
+		ext := filepath.Ext(w.linkName)
+		lw.linkName = strings.TrimSuffix(w.linkName, ext) + "_" + suffix + ext
+	}
+	lw.errorHandler = w.errorHandler
+	lw.closeHooks = append(lw.closeHooks, w.closeHooks...)
+	lw.closeCmd = w.closeCmd
+	lw.header = w.header
+	lw.moduleVer = w.moduleVer
+	lw.retention = w.retention
+	lw.clock = w.clock
+	w.levelFiles = append(w.levelFiles, levelFile{minLevel: minLevel, w: lw})
+	return lw
+}
+
+// WriteLevel : writes output of lvl, to the level files of lvl as well
+func (w *LogWriter) WriteLevel(output []byte, lvl Level) (int, error) {
+	return w.WriteLevelWithTime(output, lvl, w.now())
+}
+
+// WriteLevelWithTime : WriteLevel, the file of the day of t is written
+func (w *LogWriter) WriteLevelWithTime(output []byte, lvl Level, t time.Time) (int, error) {
+	w.queueMu.RLock()
+	defer w.queueMu.RUnlock()
+	if w.queue == nil {
+		return w.writeLevel(output, lvl, t)
+	}
+
+	// output is copied, the buffer of the caller may be reused after WriteLevelWithTime returns
+	w.queue <- logMsg{output: append([]byte(nil), output...), t: t, lvl: lvl}
+	return len(output), nil
+}
+
+func (w *LogWriter) writeLevel(output []byte, lvl Level, t time.Time) (int, error) {
+	n, err := w.WriteWithTime(output, t)
+	w.lock.Lock()
+	levelFiles := w.levelFiles
+	w.lock.Unlock()
+	for _, lf := range levelFiles {
+		if lvl.Rank() < lf.minLevel.Rank() {
+			continue
+		}
+		if _, err := lf.w.WriteWithTime(output, t); err != nil {
+			lf.w.handleError(err)
+		}
+	}
+	return n, err
+}
+
+// levelWriter : a writer which takes the level and the time of the record, e.g. LogWriter
+type levelWriter interface {
+	WriteLevelWithTime(output []byte, lvl Level, t time.Time) (int, error)
+}
+
+// bufPool : the buffers of the encoded records, w must not retain the buffer as io.Writer
+var bufPool = sync.Pool{New: func() interface{} {
+	buf := make([]byte, 0, 512)
+	return &buf
+}}
+
+// maxPooledBuf : a buffer grown by a large record is not pooled
+const maxPooledBuf = 64 << 10
+
+// writeRecord : encodes r and writes it to w, a LogWriter writes the file of the day of the record
+func writeRecord(w io.Writer, enc Encoder, r *Record) error {
+	bp := bufPool.Get().(*[]byte)
+	buf := enc.Encode((*bp)[:0], r)
+	var err error
+	if lw, ok := w.(levelWriter); ok {
+		_, err = lw.WriteLevelWithTime(buf, r.Level, r.Time)
+	} else {
+		_, err = w.Write(buf)
+	}
+	if cap(buf) <= maxPooledBuf {
+		*bp = buf
+		bufPool.Put(bp)
+	}
+	return err
+}
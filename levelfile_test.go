@@ -0,0 +1,126 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogWriter_AddLevelFile(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		idv4, _ := uuid.NewRandom()
+		dir := path.Join("ut.dir", idv4.String())
+		os.MkdirAll(dir, 0775)
+		defer os.RemoveAll(dir)
+
+		w := cilog.NewLogWriter(dir, "module", 1024)
+		w.AddLevelFile("error", cilog.ERROR)
+		if async {
+			w.Start()
+		}
+		logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+		logger.Log(1, cilog.INFO, "info", time.Now())
+		logger.Log(1, cilog.ERROR, "error", time.Now())
+		logger.Log(1, cilog.CRITICAL, "critical", time.Now())
+		w.Write([]byte("no level\n"))
+		if async {
+			w.Stop()
+		} else {
+			w.Close()
+		}
+
+		now := time.Now()
+		monthDir := filepath.Join(dir, now.Format("2006-01"))
+		b1, err := ioutil.ReadFile(filepath.Join(monthDir, now.Format("2006-01-02")+"_module.log"))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, strings.Count(string(b1), "\n"), string(b1))
+
+		b2, err := ioutil.ReadFile(filepath.Join(monthDir, now.Format("2006-01-02")+"_module_error.log"))
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSuffix(string(b2), "\n"), "\n")
+		if assert.Equal(t, 2, len(lines), string(b2)) {
+			assert.True(t, strings.HasSuffix(lines[0], ",,error"))
+			assert.True(t, strings.HasSuffix(lines[1], ",,critical"))
+		}
+
+		b3, err := ioutil.ReadFile(filepath.Join(dir, "module_error.log"))
+		assert.NoError(t, err)
+		assert.Equal(t, b2, b3)
+	}
+}
+
+func TestLogWriter_AddLevelFile_Rotate(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.SetLinkPath("", "current.log")
+	w.AddLevelFile("error", cilog.ERROR)
+	tm := time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local)
+	w.WriteWithTime([]byte("abc"), tm)
+	w.WriteLevel([]byte("def"), cilog.ERROR)
+	w.WriteLevel([]byte("ghi"), cilog.ERROR)
+	w.Close()
+
+	now := time.Now()
+	monthDir := filepath.Join(dir, now.Format("2006-01"))
+	b1, _ := ioutil.ReadFile(filepath.Join(monthDir, now.Format("2006-01-02")+"_module_error.log"))
+	assert.Equal(t, "defghi", string(b1))
+	if _, err := os.Stat(filepath.Join(monthDir, now.Format("2006-01-02")+"[1]_module_error.log")); err == nil {
+		t.Errorf("level file should not be rotated yet")
+	}
+	b2, _ := ioutil.ReadFile(filepath.Join(dir, "current_error.log"))
+	assert.Equal(t, "defghi", string(b2))
+	_, err := os.Lstat(filepath.Join(dir, "current.log"))
+	assert.NoError(t, err)
+}
+
+func TestLogPath_SimilarModule(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	monthD := filepath.Join(dir, "2009-11")
+	os.MkdirAll(monthD, 0775)
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[3]_module_error.log"), []byte("test"), 0775)
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[2]_moduleXlog"), []byte("test"), 0775)
+
+	expected := filepath.Join(dir, "2009-11", "2009-11-23_module.log")
+	v := cilog.LogPath(dir, "module", 100, time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	assert.Equal(t, expected, v)
+}
+
+func TestLogPath_MetaModule(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	monthD := filepath.Join(dir, "2009-11")
+	os.MkdirAll(monthD, 0775)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local)
+	// matched by "a.b+c[1]" if it is not quoted
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[3]_aXbbc1.log"), []byte("test"), 0775)
+	// sharing a prefix or a suffix
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[4]_a.b+c[1]_error.log"), []byte("test"), 0775)
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[5]_pre_a.b+c[1].log"), []byte("test"), 0775)
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[6]_a.b+c[1].log.old"), []byte("test"), 0775)
+	assert.Equal(t, filepath.Join(monthD, "2009-11-23_a.b+c[1].log"), cilog.LogPath(dir, "a.b+c[1]", 100, now))
+
+	// the brackets of the module are not the index
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23_a.b+c[1].log"), []byte("test"), 0775)
+	assert.Equal(t, filepath.Join(monthD, "2009-11-23_a.b+c[1].log"), cilog.LogPath(dir, "a.b+c[1]", 100, now))
+	assert.Equal(t, filepath.Join(monthD, "2009-11-23[1]_a.b+c[1].log"), cilog.LogPath(dir, "a.b+c[1]", 2, now))
+	ioutil.WriteFile(filepath.Join(monthD, "2009-11-23[1]_a.b+c[1].log"), []byte("test"), 0775)
+	assert.Equal(t, filepath.Join(monthD, "2009-11-23[2]_a.b+c[1].log"), cilog.LogPath(dir, "a.b+c[1]", 2, now))
+	assert.Equal(t, filepath.Join(monthD, "2009-11-23[1]_a.b+c[1].log"), cilog.LogPath(dir, "a.b+c[1]", 100, now))
+}
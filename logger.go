@@ -1,166 +1,160 @@
 package cilog
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"runtime"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-// Level :
-type Level int
-
-// Level enum
+// environment variables read by LoadEnv
 const (
-	_           = iota
-	DEBUG Level = iota
-	REPORT
-	INFO
-	SUCCESS
-	WARNING
-	ERROR
-	FAIL
-	EXCEPTION
-	CRITICAL
+	EnvLevel         = "CILOG_LEVEL"
+	EnvPackageLevels = "CILOG_PACKAGE_LEVELS"
 )
 
-// Output :
-func (l Level) Output() string {
-	switch l {
-	case DEBUG:
-		return "Debug"
-	case REPORT:
-		return "Report"
-	case INFO:
-		return "Information"
-	case SUCCESS:
-		return "Success"
-	case WARNING:
-		return "Warning"
-	case ERROR:
-		return "Error"
-	case FAIL:
-		return "Fail"
-	case EXCEPTION:
-		return "Exception"
-	case CRITICAL:
-		return "Critical"
-	default:
-		return ""
-	}
-}
-
-// String :
-func (l Level) String() string {
-	switch l {
-	case DEBUG:
-		return "debug"
-	case REPORT:
-		return "report"
-	case INFO:
-		return "info"
-	case SUCCESS:
-		return "success"
-	case WARNING:
-		return "warning"
-	case ERROR:
-		return "error"
-	case FAIL:
-		return "fail"
-	case EXCEPTION:
-		return "exception"
-	case CRITICAL:
-		return "critical"
-	default:
-		return ""
-	}
-}
-
-// UnmarshalYAML :
-func (l *Level) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	var s string
-	var err error
-	if err = unmarshal(&s); err != nil {
-		return err
+// LoadEnv : sets the min level and the package levels from CILOG_LEVEL and CILOG_PACKAGE_LEVELS if they are set
+func (l *Logger) LoadEnv() error {
+	if v, ok := os.LookupEnv(EnvLevel); ok {
+		lvl, err := LevelFromString(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", EnvLevel, err)
+		}
+		l.SetMinLevel(lvl)
 	}
-	if *l, err = LevelFromString(s); err != nil {
-		return err
+	if v, ok := os.LookupEnv(EnvPackageLevels); ok {
+		if err := l.SetPackageLevels(v); err != nil {
+			return fmt.Errorf("%s: %v", EnvPackageLevels, err)
+		}
 	}
 	return nil
 }
 
-// MarshalYAML :
-func (l Level) MarshalYAML() (interface{}, error) {
-	str := l.String()
-	if str == "" {
-		return "", fmt.Errorf("invalid log level, %d", int(l))
-	}
-	return str, nil
-}
-
-// LevelFromString :
-func LevelFromString(s string) (Level, error) {
-	m := map[string]Level{
-		"debug":     DEBUG,
-		"report":    REPORT,
-		"info":      INFO,
-		"success":   SUCCESS,
-		"warning":   WARNING,
-		"error":     ERROR,
-		"fail":      FAIL,
-		"exception": EXCEPTION,
-		"critical":  CRITICAL,
-	}
-	v, ok := m[s]
-	if !ok {
-		return DEBUG, fmt.Errorf("invalid level string [%s]", s)
-	}
-	return v, nil
-}
-
 // Logger :
 type Logger struct {
-	mu        sync.Mutex
-	writer    io.Writer
-	module    string
-	moduleVer string
-	minLevel  Level
+	mu           sync.RWMutex
+	writer       io.Writer
+	encoder      Encoder
+	sink         Sink
+	module       string
+	moduleVer    string
+	minLevel     Level
+	pkgLevels    []pkgLevel
+	lowestLevel  Level
+	threadID     func() string
+	staticFields Fields
+	clock        Clock
+	stack        stackOption
+	caller       callerOption
+	// pkgGen : the generation of pkgLevels, pcLevels of other generations are stale
+	pkgGen   uint64
+	pcLevels sync.Map
+	// writing : the records being written to the writer or the sink, replaced with them
+	writing *sync.WaitGroup
 }
 
 // New :
 func New(out io.Writer, module string, moduleVer string, minLevel Level) *Logger {
-	return &Logger{writer: out, module: module, moduleVer: moduleVer, minLevel: minLevel}
+	return &Logger{writer: out, module: module, moduleVer: moduleVer, minLevel: minLevel, lowestLevel: minLevel,
+		writing: new(sync.WaitGroup)}
+}
+
+// swapWriting : l.mu must be held while the writer or the sink is replaced,
+// the returned group is of the records being written to the old one, waited after l.mu is released
+func (l *Logger) swapWriting() *sync.WaitGroup {
+	old := l.writing
+	l.writing = new(sync.WaitGroup)
+	if old == nil {
+		old = new(sync.WaitGroup)
+	}
+	return old
 }
 
 // Set :
 func (l *Logger) Set(out io.Writer, module string, moduleVer string, minLevel Level) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	l.writer = out
+	l.sink = nil
 	l.module = module
 	l.moduleVer = moduleVer
 	l.minLevel = minLevel
+	l.lowestLevel = lowestLevel(minLevel, l.pkgLevels)
+	writing := l.swapWriting()
+	l.mu.Unlock()
+	writing.Wait()
 }
 
-// SetWriter :
+// SetWriter : records are encoded with the encoder of the logger and written to w,
+// the old writer is not written any more after SetWriter returns
 func (l *Logger) SetWriter(w io.Writer) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	l.writer = w
+	l.sink = nil
+	writing := l.swapWriting()
+	l.mu.Unlock()
+	writing.Wait()
+}
+
+// SetEncoder : sets the encoder used with the writer, CSVEncoder if nil
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.encoder = enc
+}
+
+// SetSink : records are written to s instead of the writer, GetWriter returns nil
+func (l *Logger) SetSink(s Sink) {
+	l.mu.Lock()
+	l.sink = s
+	l.writer = nil
+	writing := l.swapWriting()
+	l.mu.Unlock()
+	writing.Wait()
+}
+
+// GetSink : nil if the logger writes to a writer
+func (l *Logger) GetSink() Sink {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sink
+}
+
+// Flush : flushes the writer or the sink if it has Flush() error
+func (l *Logger) Flush() error {
+	l.mu.RLock()
+	var out interface{} = l.writer
+	if l.sink != nil {
+		out = l.sink
+	}
+	l.mu.RUnlock()
+	if f, ok := out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close : closes the sink if it is an io.Closer, or the writer with CloseWriter
+func (l *Logger) Close() error {
+	l.mu.RLock()
+	o := output{writer: l.writer, sink: l.sink}
+	l.mu.RUnlock()
+	return o.close()
 }
 
 // SetModule :
 func (l *Logger) SetModule(m string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.module = m
 }
 
 // SetModuleVer :
 func (l *Logger) SetModuleVer(v string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.moduleVer = v
 }
 
@@ -169,65 +163,147 @@ func (l *Logger) SetMinLevel(lvl Level) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.minLevel = lvl
+	l.lowestLevel = lowestLevel(lvl, l.pkgLevels)
 }
 
 // GetWriter :
 func (l *Logger) GetWriter() io.Writer {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.writer
 }
 
 // GetModule :
 func (l *Logger) GetModule() string {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.module
 }
 
 // GetModuleVer :
 func (l *Logger) GetModuleVer() string {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.moduleVer
 }
 
 // GetMinLevel :
 func (l *Logger) GetMinLevel() Level {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.minLevel
 }
 
-// Log : "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,,this is a example"
+// Log : "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,,this is a example",
+// the time of the clock is used if t is zero
 func (l *Logger) Log(calldepth int, lvl Level, msg string, t time.Time) {
-	if l.GetMinLevel() > lvl {
+	l.log(nil, calldepth+1, lvl, text(msg), nil, nil, t, nil)
+}
+
+// log : the fields of ctx are added to the record if ctx is not nil, and the stack of err if err has a stack
+func (l *Logger) log(ctx context.Context, calldepth int, lvl Level, msg message, args []interface{}, fn func() string, t time.Time, err error) {
+	l.mu.RLock()
+	minLevel, lowest, pkgLevels, pkgGen, threadID, clock := l.minLevel, l.lowestLevel, l.pkgLevels, l.pkgGen, l.threadID, l.clock
+	stackOpt, callerOpt := l.stack, l.caller
+	l.mu.RUnlock()
+	// no level of any package allows lvl, so runtime.Caller is not needed
+	if lowest.Rank() > lvl.Rank() {
 		return
 	}
-	timeStr := t.Format("2006-01-02,15:04:05.000000")
-	var file string
+	if len(pkgLevels) == 0 && minLevel.Rank() > lvl.Rank() {
+		return
+	}
+
+	calldepth += callerOpt.skip
+	var pkg, function, file string
 	var line int
-	var ok bool
-	var pc uintptr
-	var pkg string
-	pc, file, line, ok = runtime.Caller(calldepth)
-	if !ok {
-		file = "???"
-		line = 0
-		pkg = "???"
-	} else {
-		file = filepath.Base(file)
-		pkg = PackageBase(runtime.FuncForPC(pc).Name())
+	if callerOpt.mode != CallerNone || len(pkgLevels) > 0 {
+		pc := callerPC(calldepth)
+		if len(pkgLevels) > 0 {
+			pkgLevel := l.packageLevelAt(pc, pkgLevels, pkgGen)
+			if pkgLevel == 0 {
+				pkgLevel = minLevel
+			}
+			if pkgLevel.Rank() > lvl.Rank() {
+				return
+			}
+		}
+		if callerOpt.mode != CallerNone {
+			c := callerAt(pc)
+			pkg, function, file = callerOpt.record(c)
+			line = c.frame.Line
+		}
 	}
 
-	m := l.GetModule() + "," + l.GetModuleVer() + "," + timeStr + "," +
-		lvl.Output() + "," + pkg + "::" + file + ":" + strconv.Itoa(line) + ",," + msg
-	if len(msg) == 0 || msg[len(msg)-1] != '\n' {
-		m += "\n"
+	if t.IsZero() {
+		if clock == nil {
+			clock = SystemClock
+		}
+		t = clock.Now()
+	}
+	var tid string
+	if threadID != nil {
+		tid = threadID()
+	}
+	var fields Fields
+	if ctx != nil {
+		fields = contextFields(ctx)
+	}
+	var stack []Frame
+	if pcs := errorStack(err); pcs != nil {
+		stack = stackOpt.frames(pcs)
+	} else if stackOpt.enabled(lvl) {
+		stack = stackOpt.frames(stackOpt.callers(calldepth))
+	}
+
+	// the lock is not held while writing, so that a slow writer does not block the setters.
+	// the record is counted in writing, so that the writer replaced by SetWriter is not written after it returns
+	l.mu.RLock()
+	module, moduleVer, staticFields := l.module, l.moduleVer, l.staticFields
+	writer, enc, sink, writing := l.writer, l.encoder, l.sink, l.writing
+	if writing != nil {
+		writing.Add(1)
+	}
+	l.mu.RUnlock()
+	if writing != nil {
+		defer writing.Done()
+	}
+	if len(staticFields) > 0 {
+		fields = append(staticFields[:len(staticFields):len(staticFields)], fields...)
+	}
+	r := recordPool.Get().(*Record)
+	*r = Record{
+		Module:    module,
+		ModuleVer: moduleVer,
+		Time:      t,
+		Level:     lvl,
+		Package:   pkg,
+		Function:  function,
+		File:      file,
+		Line:      line,
+		ThreadID:  tid,
+		Fields:    fields,
+		Message:   msg.String(args, fn, err),
+		Stack:     stack,
 	}
-	l.GetWriter().Write([]byte(m))
+	if sink != nil {
+		sink.WriteRecord(r)
+	} else {
+		if enc == nil {
+			enc = defaultEncoder
+		}
+		writeRecord(writer, enc, r)
+	}
+	*r = Record{}
+	recordPool.Put(r)
 }
 
+// recordPool : the records of log, a record is not used after Sink.WriteRecord returns
+var recordPool = sync.Pool{New: func() interface{} { return new(Record) }}
+
+// defaultEncoder : CSVEncoder as an Encoder, so that it is not converted for each record
+var defaultEncoder Encoder = CSVEncoder{}
+
 var std = New(os.Stderr, "", "", DEBUG)
 
 // Set :
@@ -275,54 +351,89 @@ func GetMinLevel() Level {
 	return std.GetMinLevel()
 }
 
+// LoadEnv :
+func LoadEnv() error {
+	return std.LoadEnv()
+}
+
+// SetPackageLevels :
+func SetPackageLevels(spec string) error {
+	return std.SetPackageLevels(spec)
+}
+
+// SetPackageLevel :
+func SetPackageLevel(pattern string, lvl Level) {
+	std.SetPackageLevel(pattern, lvl)
+}
+
+// GetPackageLevels :
+func GetPackageLevels() string {
+	return std.GetPackageLevels()
+}
+
 // StdLogger :
 func StdLogger() *Logger {
 	return std
 }
 
+// Tracef :
+func Tracef(format string, v ...interface{}) {
+	std.log(nil, 2, TRACE, msgf(format), v, nil, time.Time{}, nil)
+}
+
 // Debugf :
 func Debugf(format string, v ...interface{}) {
-	std.Log(2, DEBUG, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, DEBUG, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Reportf :
 func Reportf(format string, v ...interface{}) {
-	std.Log(2, REPORT, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, REPORT, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Infof :
 func Infof(format string, v ...interface{}) {
-	std.Log(2, INFO, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, INFO, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// Noticef :
+func Noticef(format string, v ...interface{}) {
+	std.log(nil, 2, NOTICE, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Successf :
 func Successf(format string, v ...interface{}) {
-	std.Log(2, SUCCESS, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, SUCCESS, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Warningf :
 func Warningf(format string, v ...interface{}) {
-	std.Log(2, WARNING, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, WARNING, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Errorf :
 func Errorf(format string, v ...interface{}) {
-	std.Log(2, ERROR, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, ERROR, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Failf :
 func Failf(format string, v ...interface{}) {
-	std.Log(2, FAIL, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, FAIL, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Exceptionf :
 func Exceptionf(format string, v ...interface{}) {
-	std.Log(2, EXCEPTION, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, EXCEPTION, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // Criticalf :
 func Criticalf(format string, v ...interface{}) {
-	std.Log(2, CRITICAL, fmt.Sprintf(format, v...), time.Now())
+	std.log(nil, 2, CRITICAL, msgf(format), v, nil, time.Time{}, nil)
+}
+
+// Alertf :
+func Alertf(format string, v ...interface{}) {
+	std.log(nil, 2, ALERT, msgf(format), v, nil, time.Time{}, nil)
 }
 
 // PackageBase : funcName string format : runtime.FuncForPC(pc).Name()
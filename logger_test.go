@@ -1,6 +1,9 @@
 package cilog_test
 
 import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
 	"os"
 	"path"
 	"runtime"
@@ -93,6 +96,41 @@ func BenchmarkLogger_WithDummyWriter(b *testing.B) {
 	}
 }
 
+func BenchmarkLogger_Log(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.DEBUG)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		logger.Log(1, cilog.INFO, "this is log", time.Time{})
+	}
+}
+
+func BenchmarkLogger_Logf(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.DEBUG)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		logger.Logf(1, cilog.INFO, time.Time{}, "this is log. name:%s", "abc")
+	}
+}
+
+func BenchmarkLogger_Log_JSON(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.DEBUG)
+	logger.SetEncoder(cilog.JSONEncoder{})
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		logger.Log(1, cilog.INFO, "this is log", time.Time{})
+	}
+}
+
+func BenchmarkLogger_Log_Parallel(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.DEBUG)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Log(1, cilog.INFO, "this is log", time.Time{})
+		}
+	})
+}
+
 func BenchmarkLogger_WithLogWriter(b *testing.B) {
 	idv4, _ := uuid.NewRandom()
 	dir := path.Join("ut.dir", idv4.String())
@@ -256,6 +294,11 @@ func TestLevel_UnmarshalYAML(t *testing.T) {
 			data:    "level: unknown",
 			wantErr: true,
 		},
+		{
+			name:    "unmarshal empty error",
+			data:    `level: ""`,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		ret := struct {
@@ -352,3 +395,201 @@ func TestLevel_MarshalYAML(t *testing.T) {
 		}
 	}
 }
+
+func TestLevelFromString(t *testing.T) {
+	tests := []struct {
+		str     string
+		level   cilog.Level
+		wantErr bool
+	}{
+		{str: "debug", level: cilog.DEBUG},
+		{str: "DEBUG", level: cilog.DEBUG},
+		{str: " Report ", level: cilog.REPORT},
+		{str: "info", level: cilog.INFO},
+		{str: "Information", level: cilog.INFO},
+		{str: "success", level: cilog.SUCCESS},
+		{str: "Warning", level: cilog.WARNING},
+		{str: "warn", level: cilog.WARNING},
+		{str: "error", level: cilog.ERROR},
+		{str: "ERR", level: cilog.ERROR},
+		{str: "fail", level: cilog.FAIL},
+		{str: "exception", level: cilog.EXCEPTION},
+		{str: "critical", level: cilog.CRITICAL},
+		{str: "fatal", level: cilog.CRITICAL},
+		{str: "unknown", wantErr: true},
+		{str: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		lvl, err := cilog.LevelFromString(tt.str)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LevelFromString(%s) error = %v, wantErr %v", tt.str, err, tt.wantErr)
+		}
+		if err == nil {
+			assert.Equal(t, tt.level, lvl, "%s", tt.str)
+		}
+	}
+}
+
+func TestLevel_FlagValue(t *testing.T) {
+	lvl := cilog.INFO
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&lvl, "level", "log level")
+	if err := fs.Parse([]string{"-level", "Warn"}); err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, cilog.WARNING, lvl)
+	assert.Error(t, fs.Parse([]string{"-level", "unknown"}))
+}
+
+func TestLevel_JSON(t *testing.T) {
+	type testLevel struct {
+		Level cilog.Level `json:"level"`
+	}
+	out, err := json.Marshal(testLevel{cilog.EXCEPTION})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"exception"}`, string(out))
+
+	_, err = json.Marshal(testLevel{-1})
+	assert.Error(t, err)
+
+	// the unset level
+	out, err = json.Marshal(testLevel{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":""}`, string(out))
+	// an empty level is an error, not the unset level
+	assert.Error(t, json.Unmarshal(out, &testLevel{}))
+	_, err = cilog.ParseLine(`{"module":"module","level":"","message":"abc"}`)
+	assert.Error(t, err)
+
+	var v testLevel
+	assert.NoError(t, json.Unmarshal([]byte(`{"level":"Information"}`), &v))
+	assert.Equal(t, cilog.INFO, v.Level)
+	assert.Error(t, json.Unmarshal([]byte(`{"level":"unknown"}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"level":3}`), &v))
+}
+
+func TestLevel_Text(t *testing.T) {
+	out, err := cilog.FAIL.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "fail", string(out))
+	out, err = cilog.Level(0).MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(out))
+	var lvl cilog.Level
+	assert.Error(t, lvl.UnmarshalText(out))
+
+	m := map[cilog.Level]int{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"debug":1,"Critical":2}`), &m))
+	assert.Equal(t, map[cilog.Level]int{cilog.DEBUG: 1, cilog.CRITICAL: 2}, m)
+}
+
+func TestLogger_LoadEnv(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.DEBUG)
+	t.Setenv(cilog.EnvLevel, "warn")
+	t.Setenv(cilog.EnvPackageLevels, "cache=debug")
+	assert.NoError(t, logger.LoadEnv())
+	assert.Equal(t, cilog.WARNING, logger.GetMinLevel())
+	assert.Equal(t, "cache=debug", logger.GetPackageLevels())
+
+	t.Setenv(cilog.EnvLevel, "unknown")
+	assert.Error(t, logger.LoadEnv())
+	assert.Equal(t, cilog.WARNING, logger.GetMinLevel())
+}
+
+// gateWriter : blocks the first write until release is closed
+type gateWriter struct {
+	once    sync.Once
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *gateWriter) Write(output []byte) (int, error) {
+	w.once.Do(func() { close(w.writing) })
+	<-w.release
+	return len(output), nil
+}
+
+func TestLogger_SlowWriter(t *testing.T) {
+	slow := &gateWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	logger := cilog.New(slow, "module", "1.0", cilog.DEBUG)
+	written := make(chan struct{})
+	go func() {
+		logger.Log(1, cilog.INFO, "abc", time.Now())
+		close(written)
+	}()
+	<-slow.writing
+
+	// the setters are not blocked by the writer being written
+	done := make(chan struct{})
+	go func() {
+		logger.SetMinLevel(cilog.INFO)
+		logger.SetModule("module2")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a slow writer blocks the setters")
+	}
+
+	// SetWriter returns after the record being written to the old writer
+	w := &syncStringWriter{}
+	swapped := make(chan struct{})
+	go func() {
+		logger.SetWriter(w)
+		close(swapped)
+	}()
+	select {
+	case <-swapped:
+		t.Fatal("SetWriter returns while the old writer is written")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(slow.release)
+	<-written
+	<-swapped
+	logger.Log(1, cilog.INFO, "def", time.Now())
+	assert.True(t, strings.HasPrefix(w.String(), "module2,"), w.String())
+}
+
+// reentrantWriter : logs through the logger while writing
+type reentrantWriter struct {
+	logger  *cilog.Logger
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *reentrantWriter) Write(output []byte) (int, error) {
+	if strings.HasSuffix(string(output), ",outer\n") {
+		close(w.writing)
+		<-w.release
+		w.logger.Log(1, cilog.INFO, "inner", time.Now())
+	}
+	return len(output), nil
+}
+
+func TestLogger_ReentrantWriter(t *testing.T) {
+	w := &reentrantWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	w.logger = logger
+	done := make(chan struct{})
+	go func() {
+		logger.Log(1, cilog.INFO, "outer", time.Now())
+		close(done)
+	}()
+	<-w.writing
+	swapped := make(chan struct{})
+	go func() {
+		logger.SetWriter(&syncStringWriter{})
+		close(swapped)
+	}()
+	// the writer logs while SetWriter waits for it
+	time.Sleep(50 * time.Millisecond)
+	close(w.release)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a writer logging through the logger deadlocks")
+	}
+	<-swapped
+}
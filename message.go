@@ -0,0 +1,44 @@
+package cilog
+
+import (
+	"fmt"
+)
+
+// message : the message of a record, formatted after the level check so that filtered records do not format.
+// the arguments of format still escape to fmt, so boxing non-constant arguments allocates even for filtered records,
+// hot loops should check Enabled or log with the *Func functions
+type message struct {
+	format string
+	// raw : format is the message itself
+	raw bool
+	// withErr : the message is "message: err", or "err" if the message is empty
+	withErr bool
+}
+
+func text(s string) message {
+	return message{format: s, raw: true}
+}
+
+func msgf(format string) message {
+	return message{format: format}
+}
+
+// String : the message of fn if fn is not nil
+func (m *message) String(args []interface{}, fn func() string, err error) string {
+	var s string
+	switch {
+	case fn != nil:
+		s = fn()
+	case m.raw:
+		s = m.format
+	default:
+		s = fmt.Sprintf(m.format, args...)
+	}
+	if !m.withErr || err == nil {
+		return s
+	}
+	if s == "" {
+		return err.Error()
+	}
+	return s + ": " + err.Error()
+}
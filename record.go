@@ -0,0 +1,214 @@
+package cilog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record : a log record, the columns of a line written by Logger.Log
+type Record struct {
+	Module    string
+	ModuleVer string
+	Time      time.Time
+	Level     Level
+	Package   string
+	// Function : the function of the caller without the package, "(*Server).Serve", see Logger.SetCaller
+	Function string
+	File     string
+	Line     int
+	ThreadID string
+	Fields   Fields
+	Message  string
+	// Stack : the stack of the caller or of the error, see Logger.SetStackTrace
+	Stack []Frame
+}
+
+// ParseLine : parses a line written by Logger.Log with CSVEncoder or JSONEncoder of any TimeFormat,
+// the time without a zone is parsed in the local time zone.
+// "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,18 request_id=abc,this is a example"
+func ParseLine(line string) (Record, error) {
+	if strings.HasPrefix(line, "{") {
+		return parseJSONLine(line)
+	}
+	var r Record
+	line = strings.TrimSuffix(line, "\n")
+	cols := strings.SplitN(line, ",", 8)
+	if len(cols) < 3 {
+		return r, fmt.Errorf("invalid log line [%s]", line)
+	}
+	var ts string
+	if strings.IndexByte(cols[2], 'T') >= 0 || strings.IndexByte(cols[2], '-') == -1 {
+		// ISO-8601 or epoch, the date and the time are a column
+		cols = strings.SplitN(line, ",", 7)
+		if len(cols) != 7 {
+			return r, fmt.Errorf("invalid log line [%s]", line)
+		}
+		ts = cols[2]
+		cols = append(cols[:3], cols[2:]...)
+	} else {
+		if len(cols) != 8 {
+			return r, fmt.Errorf("invalid log line [%s]", line)
+		}
+		ts = cols[2] + "," + cols[3]
+	}
+	t, err := parseTime(ts, time.Local)
+	if err != nil {
+		return r, fmt.Errorf("invalid log time [%s]", ts)
+	}
+	lvl, err := LevelFromString(cols[4])
+	if err != nil {
+		return r, err
+	}
+	pkg, function, file, lineNum, err := parseCaller(cols[5])
+	if err != nil {
+		return r, err
+	}
+	tid, fields := parseContextColumn(cols[6])
+	r = Record{
+		Module:    cols[0],
+		ModuleVer: cols[1],
+		Time:      t,
+		Level:     lvl,
+		Package:   pkg,
+		Function:  function,
+		File:      file,
+		Line:      lineNum,
+		ThreadID:  tid,
+		Fields:    fields,
+		Message:   cols[7],
+	}
+	return r, nil
+}
+
+type jsonRecord struct {
+	Module    string          `json:"module"`
+	ModuleVer string          `json:"moduleVer"`
+	Time      json.RawMessage `json:"time"`
+	Level     Level           `json:"level"`
+	Package   string          `json:"package"`
+	Function  string          `json:"function"`
+	File      string          `json:"file"`
+	Line      int             `json:"line"`
+	ThreadID  string          `json:"thread"`
+	Fields    Fields          `json:"fields"`
+	Message   string          `json:"message"`
+	Stack     []string        `json:"stack"`
+}
+
+func parseJSONLine(line string) (Record, error) {
+	var j jsonRecord
+	if err := json.Unmarshal([]byte(line), &j); err != nil {
+		return Record{}, fmt.Errorf("invalid log line [%s], %v", line, err)
+	}
+	// a string of ISO-8601 or a number of epoch
+	ts := strings.Trim(string(j.Time), `"`)
+	t, err := parseTime(ts, time.Local)
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid log time [%s]", ts)
+	}
+	r := Record{
+		Module:    j.Module,
+		ModuleVer: j.ModuleVer,
+		Time:      t,
+		Level:     j.Level,
+		Package:   j.Package,
+		Function:  j.Function,
+		File:      j.File,
+		Line:      j.Line,
+		ThreadID:  j.ThreadID,
+		Fields:    j.Fields,
+		Message:   j.Message,
+	}
+	for _, s := range j.Stack {
+		if f, ok := parseFrame(s); ok {
+			r.Stack = append(r.Stack, f)
+		}
+	}
+	return r, nil
+}
+
+// parseCaller : "package1::src.go:56", or "package1.(*Server).Serve::src.go:56" with the function.
+// the caller is empty if the logger writes no caller
+func parseCaller(s string) (pkg string, function string, file string, line int, err error) {
+	if s == "" {
+		return "", "", "", 0, nil
+	}
+	i := strings.Index(s, "::")
+	j := strings.LastIndex(s, ":")
+	if i == -1 || j <= i+1 {
+		return "", "", "", 0, fmt.Errorf("invalid log caller [%s]", s)
+	}
+	if line, err = strconv.Atoi(s[j+1:]); err != nil {
+		return "", "", "", 0, fmt.Errorf("invalid log caller [%s]", s)
+	}
+	// the dots of the last element of a package path are escaped in function names, "gopkg.in/yaml%2ev2.Marshal"
+	pkg = functionPackage(s[:i])
+	if len(pkg) < i {
+		function = s[len(pkg)+1 : i]
+	}
+	return pkg, function, s[i+2 : j], line, nil
+}
+
+// Reader : reads records from a log file.
+// lines which are not records (e.g. a message with new lines) are appended to the message of the previous record,
+// or to the stack if they are frames of CSVEncoder, such lines before the first record are skipped.
+type Reader struct {
+	scanner *bufio.Scanner
+	pending *Record
+	rec     Record
+	err     error
+}
+
+// NewReader :
+func NewReader(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Reader{scanner: s}
+}
+
+// Next : advances to the next record, returns false at the end or on an error
+func (r *Reader) Next() bool {
+	for r.pending == nil && r.scanner.Scan() {
+		if rec, err := ParseLine(r.scanner.Text()); err == nil {
+			r.pending = &rec
+		}
+	}
+	if r.pending == nil {
+		r.err = r.scanner.Err()
+		return false
+	}
+
+	r.rec = *r.pending
+	r.pending = nil
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if rec, err := ParseLine(line); err == nil {
+			r.pending = &rec
+			return true
+		}
+		if strings.HasPrefix(line, stackPrefix) {
+			if f, ok := parseFrame(line[len(stackPrefix):]); ok {
+				r.rec.Stack = append(r.rec.Stack, f)
+				continue
+			}
+		}
+		r.rec.Message += "\n" + line
+	}
+	r.err = r.scanner.Err()
+	return true
+}
+
+// Record : the current record
+func (r *Reader) Record() Record {
+	return r.rec
+}
+
+// Err : the error stopped Next, nil at the end
+func (r *Reader) Err() error {
+	return r.err
+}
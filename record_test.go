@@ -0,0 +1,72 @@
+package cilog_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	r, err := cilog.ParseLine("module,1.0,2009-11-23,15:21:30.123456,Information,package1::src.go:56,,this is, a example\n")
+	assert.NoError(t, err)
+	assert.Equal(t, cilog.Record{
+		Module:    "module",
+		ModuleVer: "1.0",
+		Time:      time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.Local),
+		Level:     cilog.INFO,
+		Package:   "package1",
+		File:      "src.go",
+		Line:      56,
+		Message:   "this is, a example",
+	}, r)
+
+	for _, line := range []string{
+		"",
+		"this is not a log",
+		"module,1.0,2009-11-23,15:21,Information,package1::src.go:56,,msg",
+		"module,1.0,2009-11-23,15:21:30.123456,Unknown,package1::src.go:56,,msg",
+		"module,1.0,2009-11-23,15:21:30.123456,Information,src.go:56,,msg",
+		"module,1.0,2009-11-23,15:21:30.123456,Information,package1::src.go:abc,,msg",
+	} {
+		_, err := cilog.ParseLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestParseLine_Logger(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.TRACE)
+	tm := time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.Local)
+	logger.Log(1, cilog.TRACE, "abc", tm)
+
+	r, err := cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	assert.Equal(t, cilog.TRACE, r.Level)
+	assert.Equal(t, tm, r.Time)
+	assert.Equal(t, "cilog_test", r.Package)
+	assert.Equal(t, "record_test.go", r.File)
+	assert.Equal(t, "abc", r.Message)
+}
+
+func TestReader(t *testing.T) {
+	data := "garbage\n" +
+		"module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,,first\n" +
+		"module,1.0,2009-11-23,15:21:31.000000,Error,package1::src.go:57,,second\n" +
+		"continued\n" +
+		"\n" +
+		"module,1.0,2009-11-23,15:21:32.000000,Alert,package1::src.go:58,,third\n"
+	r := cilog.NewReader(strings.NewReader(data))
+
+	var msgs []string
+	var levels []cilog.Level
+	for r.Next() {
+		msgs = append(msgs, r.Record().Message)
+		levels = append(levels, r.Record().Level)
+	}
+	assert.NoError(t, r.Err())
+	assert.Equal(t, []string{"first", "second\ncontinued\n", "third"}, msgs)
+	assert.Equal(t, []cilog.Level{cilog.DEBUG, cilog.ERROR, cilog.ALERT}, levels)
+}
@@ -0,0 +1,153 @@
+package cilog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// panicked : the stack of the panic and the depth of the function which panicked from the caller of panicked
+func panicked() (pcs []uintptr, depth int) {
+	pcs = make([]uintptr, DefaultStackDepth+16)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	// the deferred function is called by runtime.gopanic, e.g. panicked <- handlePanic <- Recover <- runtime.gopanic
+	// <- (runtime.panicmem <- runtime.sigpanic) <- the function which panicked
+	start := -1
+	for i, pc := range pcs {
+		name := funcName(pc)
+		if start == -1 && name == "runtime.gopanic" {
+			start = i + 1
+			continue
+		}
+		if start != -1 && strings.HasPrefix(name, "runtime.") {
+			start = i + 1
+			continue
+		}
+		if start != -1 {
+			break
+		}
+	}
+	if start == -1 || start >= len(pcs) {
+		return pcs, 1
+	}
+	frames := runtime.CallersFrames(pcs[:start])
+	for {
+		_, more := frames.Next()
+		depth++
+		if !more {
+			break
+		}
+	}
+	return pcs[start:], depth - 1
+}
+
+func funcName(pc uintptr) string {
+	if f := runtime.FuncForPC(pc - 1); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// handlePanic : logs v with the stack of the panic at CRITICAL and flushes the logger
+func (l *Logger) handlePanic(ctx context.Context, v interface{}, msg string) {
+	pcs, depth := panicked()
+	m := fmt.Sprintf("panic: %v", v)
+	if msg != "" {
+		m += ", " + msg
+	}
+	l.mu.RLock()
+	skip := l.caller.skip
+	l.mu.RUnlock()
+	// depth is from handlePanic, and log is called by handlePanic.
+	// the caller skip of the wrappers of the logger is not of the function which panicked
+	l.log(ctx, depth+1-skip, CRITICAL, text(m), nil, nil, time.Time{}, &stackError{err: fmt.Errorf("%v", v), pcs: pcs})
+	l.Flush()
+}
+
+// Recover : recovers a panic, logs the value and the stack at CRITICAL and flushes the logger, "defer l.Recover()".
+// the goroutine returns from the function of the defer
+func (l *Logger) Recover() {
+	if v := recover(); v != nil {
+		l.handlePanic(nil, v, "")
+	}
+}
+
+// RecoverAndPanic : Recover and panics again with the value
+func (l *Logger) RecoverAndPanic() {
+	if v := recover(); v != nil {
+		l.handlePanic(nil, v, "")
+		panic(v)
+	}
+}
+
+// RecoverAndExit : Recover, runs the exit hooks and exits with code, see SetExitFunc
+func (l *Logger) RecoverAndExit(code int) {
+	if v := recover(); v != nil {
+		l.handlePanic(nil, v, "")
+		exit(code)
+	}
+}
+
+// Go : runs f in a new goroutine, a panic of f is recovered and logged
+func (l *Logger) Go(f func()) {
+	go func() {
+		defer l.Recover()
+		f()
+	}()
+}
+
+// RecoverHandler : an HTTP middleware, a panic of next is logged with the method and the URL of the request
+// and the fields of its context, and 500 Internal Server Error is returned.
+// http.ErrAbortHandler is not logged and is panicked again to abort the response
+func (l *Logger) RecoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			l.handlePanic(r.Context(), v, r.Method+" "+r.URL.String())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Recover :
+func Recover() {
+	if v := recover(); v != nil {
+		std.handlePanic(nil, v, "")
+	}
+}
+
+// RecoverAndPanic :
+func RecoverAndPanic() {
+	if v := recover(); v != nil {
+		std.handlePanic(nil, v, "")
+		panic(v)
+	}
+}
+
+// RecoverAndExit :
+func RecoverAndExit(code int) {
+	if v := recover(); v != nil {
+		std.handlePanic(nil, v, "")
+		exit(code)
+	}
+}
+
+// Go :
+func Go(f func()) {
+	std.Go(f)
+}
+
+// RecoverHandler :
+func RecoverHandler(next http.Handler) http.Handler {
+	return std.RecoverHandler(next)
+}
@@ -0,0 +1,159 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func panicWithRecover(logger *cilog.Logger) (line int) {
+	defer logger.Recover()
+	_, _, line, _ = runtime.Caller(0)
+	panic("boom")
+}
+
+func nilPanicWithRecover(logger *cilog.Logger) (line int) {
+	defer logger.Recover()
+	var m map[string]int
+	_, _, line, _ = runtime.Caller(0)
+	m["a"] = 1
+	return 0
+}
+
+func TestLogger_Recover(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	line := panicWithRecover(logger) + 1
+	_, file, _, _ := runtime.Caller(0)
+	file = filepath.Base(file)
+
+	r := cilog.NewReader(strings.NewReader(w.writed))
+	if assert.True(t, r.Next()) {
+		rec := r.Record()
+		assert.Equal(t, cilog.CRITICAL, rec.Level)
+		assert.Equal(t, "panic: boom", rec.Message)
+		assert.Equal(t, file, rec.File)
+		assert.Equal(t, line, rec.Line, w.writed)
+		if assert.True(t, len(rec.Stack) > 1, w.writed) {
+			assert.Equal(t, "github.com/castisdev/cilog_test.panicWithRecover", rec.Stack[0].Function)
+			assert.Equal(t, line, rec.Stack[0].Line)
+			assert.Equal(t, "github.com/castisdev/cilog_test.TestLogger_Recover", rec.Stack[1].Function)
+		}
+	}
+
+	w.writed = ""
+	line = nilPanicWithRecover(logger) + 1
+	r = cilog.NewReader(strings.NewReader(w.writed))
+	if assert.True(t, r.Next()) {
+		rec := r.Record()
+		assert.Equal(t, "panic: assignment to entry in nil map", rec.Message)
+		assert.Equal(t, line, rec.Line, w.writed)
+		if assert.True(t, len(rec.Stack) > 0, w.writed) {
+			assert.Equal(t, "github.com/castisdev/cilog_test.nilPanicWithRecover", rec.Stack[0].Function)
+		}
+	}
+}
+
+func TestLogger_RecoverAndPanic(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	var v interface{}
+	func() {
+		defer func() { v = recover() }()
+		func() {
+			defer logger.RecoverAndPanic()
+			panic("boom")
+		}()
+	}()
+	assert.Equal(t, "boom", v)
+	assert.Contains(t, w.writed, ",Critical,")
+	assert.Contains(t, w.writed, ",,panic: boom\n")
+}
+
+func TestLogger_Go(t *testing.T) {
+	w := &syncStringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.Go(func() {
+		panic("boom")
+	})
+	assert.True(t, waitFor(func() bool {
+		return strings.Contains(w.String(), ",,panic: boom\n\tat github.com/castisdev/cilog_test.TestLogger_Go.func1(")
+	}), w.String())
+}
+
+func TestLogger_Recover_Flush(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	// the queued lines are written before Recover returns
+	w := cilog.NewLogWriter(dir, "module", 1024*1024)
+	w.StartWithBufferSize(16)
+	defer w.Stop()
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer logger.Recover()
+		logger.Log(1, cilog.INFO, "before panic", time.Time{})
+		panic("boom")
+	}()
+	<-done
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "module.log"))
+	assert.Contains(t, string(b), ",,before panic\n")
+	assert.Contains(t, string(b), ",,panic: boom\n")
+}
+
+func TestLogger_RecoverHandler(t *testing.T) {
+	w := &syncStringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	h := logger.RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/abort" {
+			panic(http.ErrAbortHandler)
+		}
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest("GET", "/path?a=1", nil)
+	req = req.WithContext(cilog.WithRequestID(req.Context(), "req1"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, w.String(), ",Critical,")
+	assert.Contains(t, w.String(), ",request_id=req1,panic: boom, GET /path?a=1\n")
+
+	var v interface{}
+	func() {
+		defer func() { v = recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	}()
+	assert.Equal(t, http.ErrAbortHandler, v)
+	assert.Equal(t, 1, strings.Count(w.String(), "panic"), w.String())
+}
+
+func TestRecover(t *testing.T) {
+	w := &stringWriter{}
+	prev := cilog.GetWriter()
+	cilog.SetWriter(w)
+	defer cilog.SetWriter(prev)
+	var line int
+	func() {
+		defer cilog.Recover()
+		_, _, line, _ = runtime.Caller(0)
+		panic("boom " + strconv.Itoa(1))
+	}()
+	assert.Contains(t, w.writed, ":"+strconv.Itoa(line+1)+",,panic: boom 1\n")
+}
@@ -0,0 +1,61 @@
+package cilog
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// SetRetention : log files older than days are removed whenever a new file is opened, 0 keeps all files
+func (w *LogWriter) SetRetention(days int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.retention = days
+}
+
+// removeOldLogs : w.lock must be held, files are removed in a separate goroutine
+func (w *LogWriter) removeOldLogs(t time.Time) {
+	dir, module, before := w.dir, w.module, t.AddDate(0, 0, -w.retention)
+	w.hookWG.Add(1)
+	go func() {
+		defer w.hookWG.Done()
+		if err := RemoveOldLogs(dir, module, before); err != nil {
+			w.handleError(err)
+		}
+	}()
+}
+
+// RemoveOldLogs : removes log files of module in dir dated before the day of before,
+// and month directories left empty
+func RemoveOldLogs(dir string, module string, before time.Time) error {
+	regx := regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2})(\[[0-9]+\])?_` + regexp.QuoteMeta(module) + `\.log$`)
+	monthRegx := regexp.MustCompile(`^[0-9]{4}-[0-9]{2}$`)
+	limit := before.Format("2006-01-02")
+
+	var firstErr error
+	var monthDirs []string
+	filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if f.IsDir() {
+			if monthRegx.MatchString(f.Name()) {
+				monthDirs = append(monthDirs, path)
+			}
+			return nil
+		}
+		// "2006-01-02" strings are ordered as the dates
+		if m := regx.FindStringSubmatch(f.Name()); m != nil && m[1] < limit {
+			if err := os.Remove(path); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return nil
+	})
+	for _, d := range monthDirs {
+		// fails if not empty
+		os.Remove(d)
+	}
+	return firstErr
+}
@@ -0,0 +1,193 @@
+package cilog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Sink : receives records from a Logger, a record must not be used after WriteRecord returns
+type Sink interface {
+	WriteRecord(r *Record) error
+}
+
+// WriterSink : writes records to an io.Writer with an Encoder
+type WriterSink struct {
+	writer  io.Writer
+	encoder Encoder
+}
+
+// NewWriterSink : enc is CSVEncoder if nil
+func NewWriterSink(w io.Writer, enc Encoder) *WriterSink {
+	if enc == nil {
+		enc = CSVEncoder{}
+	}
+	return &WriterSink{writer: w, encoder: enc}
+}
+
+// WriteRecord :
+func (s *WriterSink) WriteRecord(r *Record) error {
+	return writeRecord(s.writer, s.encoder, r)
+}
+
+// Writer :
+func (s *WriterSink) Writer() io.Writer {
+	return s.writer
+}
+
+// Flush :
+func (s *WriterSink) Flush() error {
+	if f, ok := s.writer.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close : closes the writer with CloseWriter
+func (s *WriterSink) Close() error {
+	return CloseWriter(s.writer)
+}
+
+// FanoutSink : writes records to several sinks, each of them has its own min level.
+// a branch with a queue is written by its own goroutine, so a slow branch does not block the others,
+// records are dropped if its queue is full.
+type FanoutSink struct {
+	branches []*fanoutBranch
+	// mu : guards closed, the queues are closed by Close while no one sends to them
+	mu     sync.RWMutex
+	closed bool
+}
+
+// ErrSinkClosed : returned by the sink written or flushed after Close
+var ErrSinkClosed = errors.New("cilog: sink is closed")
+
+type fanoutBranch struct {
+	sink     Sink
+	minLevel Level
+	queue    chan fanoutMsg
+	done     chan struct{}
+	dropped  int64
+}
+
+type fanoutMsg struct {
+	r       Record
+	flushed chan struct{}
+}
+
+// NewFanoutSink :
+func NewFanoutSink() *FanoutSink {
+	return &FanoutSink{}
+}
+
+// Add : adds a branch written with records of minLevel or above.
+// the branch is written synchronously if queueSize is 0. Add must not be called after the sink is used.
+func (f *FanoutSink) Add(s Sink, minLevel Level, queueSize int) *FanoutSink {
+	b := &fanoutBranch{sink: s, minLevel: minLevel}
+	if queueSize > 0 {
+		b.queue = make(chan fanoutMsg, queueSize)
+		b.done = make(chan struct{})
+		go b.serve()
+	}
+	f.branches = append(f.branches, b)
+	return f
+}
+
+// AddWriter : adds a branch writing to w with enc
+func (f *FanoutSink) AddWriter(w io.Writer, enc Encoder, minLevel Level, queueSize int) *FanoutSink {
+	return f.Add(NewWriterSink(w, enc), minLevel, queueSize)
+}
+
+// WriteRecord : returns the first error of the synchronous branches, ErrSinkClosed after Close
+func (f *FanoutSink) WriteRecord(r *Record) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return ErrSinkClosed
+	}
+	var firstErr error
+	for _, b := range f.branches {
+		if r.Level.Rank() < b.minLevel.Rank() {
+			continue
+		}
+		if b.queue == nil {
+			if err := b.sink.WriteRecord(r); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		select {
+		case b.queue <- fanoutMsg{r: *r}:
+		default:
+			atomic.AddInt64(&b.dropped, 1)
+		}
+	}
+	return firstErr
+}
+
+func (b *fanoutBranch) serve() {
+	defer close(b.done)
+	for msg := range b.queue {
+		if msg.flushed != nil {
+			close(msg.flushed)
+			continue
+		}
+		b.sink.WriteRecord(&msg.r)
+	}
+}
+
+// Dropped : the number of records dropped by each branch, in the order of Add
+func (f *FanoutSink) Dropped() []int64 {
+	dropped := make([]int64, len(f.branches))
+	for i, b := range f.branches {
+		dropped[i] = atomic.LoadInt64(&b.dropped)
+	}
+	return dropped
+}
+
+// Flush : waits until the queued records are written, and flushes the branches, ErrSinkClosed after Close
+func (f *FanoutSink) Flush() error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return ErrSinkClosed
+	}
+	var firstErr error
+	for _, b := range f.branches {
+		if b.queue != nil {
+			flushed := make(chan struct{})
+			b.queue <- fanoutMsg{flushed: flushed}
+			<-flushed
+		}
+		if fl, ok := b.sink.(interface{ Flush() error }); ok {
+			if err := fl.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Close : writes the queued records and closes the branches, Close of the closed sink does nothing
+func (f *FanoutSink) Close() error {
+	f.mu.Lock()
+	closed := f.closed
+	f.closed = true
+	f.mu.Unlock()
+	if closed {
+		return nil
+	}
+	var firstErr error
+	for _, b := range f.branches {
+		if b.queue != nil {
+			close(b.queue)
+			<-b.done
+		}
+		if c, ok := b.sink.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,136 @@
+package cilog_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+type syncStringWriter struct {
+	mu     sync.Mutex
+	writed string
+}
+
+func (w *syncStringWriter) Write(output []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writed += string(output)
+	return len(output), nil
+}
+
+func (w *syncStringWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writed
+}
+
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(output []byte) (int, error) {
+	<-w.release
+	return len(output), nil
+}
+
+func TestFanoutSink(t *testing.T) {
+	all := &syncStringWriter{}
+	warning := &syncStringWriter{}
+	alerts := &syncStringWriter{}
+	f := cilog.NewFanoutSink().
+		AddWriter(all, nil, cilog.DEBUG, 0).
+		AddWriter(warning, cilog.JSONEncoder{}, cilog.WARNING, 16).
+		AddWriter(alerts, nil, cilog.ERROR, 0)
+
+	logger := cilog.New(nil, "module", "1.0", cilog.DEBUG)
+	logger.SetSink(f)
+	assert.Nil(t, logger.GetWriter())
+	assert.Equal(t, f, logger.GetSink())
+
+	tm := time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local)
+	logger.Log(1, cilog.INFO, "info", tm)
+	logger.Log(1, cilog.WARNING, "warning", tm)
+	logger.Log(1, cilog.ERROR, "error", tm)
+	assert.NoError(t, logger.Flush())
+
+	assert.Equal(t, 3, strings.Count(all.String(), "\n"))
+	assert.Equal(t, 2, strings.Count(warning.String(), "\n"))
+	assert.True(t, strings.HasPrefix(warning.String(), `{"module":"module"`), warning.String())
+	assert.Equal(t, 1, strings.Count(alerts.String(), "\n"))
+	assert.True(t, strings.HasSuffix(alerts.String(), ",,error\n"), alerts.String())
+	assert.NoError(t, logger.Close())
+}
+
+func TestFanoutSink_AfterClose(t *testing.T) {
+	w := &syncStringWriter{}
+	f := cilog.NewFanoutSink().AddWriter(w, nil, cilog.DEBUG, 16)
+	logger := cilog.New(nil, "module", "1.0", cilog.DEBUG)
+	logger.SetSink(f)
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.NoError(t, f.Close())
+	assert.Equal(t, 1, strings.Count(w.String(), "\n"))
+
+	r := cilog.Record{Module: "module", Level: cilog.INFO, Message: "abc"}
+	assert.Equal(t, cilog.ErrSinkClosed, f.WriteRecord(&r))
+	assert.Equal(t, cilog.ErrSinkClosed, f.Flush())
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.NoError(t, f.Close())
+	assert.Equal(t, 1, strings.Count(w.String(), "\n"))
+}
+
+func TestFanoutSink_CloseConcurrently(t *testing.T) {
+	f := cilog.NewFanoutSink().AddWriter(&syncStringWriter{}, nil, cilog.DEBUG, 4)
+	logger := cilog.New(nil, "module", "1.0", cilog.DEBUG)
+	logger.SetSink(f)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				logger.Log(1, cilog.INFO, "abc", time.Now())
+				if n%10 == 0 {
+					f.Flush()
+				}
+			}
+		}()
+	}
+	f.Close()
+	wg.Wait()
+}
+
+func TestFanoutSink_SlowBranch(t *testing.T) {
+	fast := &syncStringWriter{}
+	slow := &blockingWriter{release: make(chan struct{})}
+	f := cilog.NewFanoutSink().
+		AddWriter(slow, nil, cilog.DEBUG, 2).
+		AddWriter(fast, nil, cilog.DEBUG, 0)
+
+	logger := cilog.New(nil, "module", "1.0", cilog.DEBUG)
+	logger.SetSink(f)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.Log(1, cilog.INFO, "abc", time.Now())
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow branch blocks logging")
+	}
+	assert.Equal(t, 10, strings.Count(fast.String(), "\n"))
+	dropped := f.Dropped()
+	// one record is being written, two are queued
+	assert.True(t, dropped[0] >= 7, "dropped %v", dropped)
+	assert.Equal(t, int64(0), dropped[1])
+
+	close(slow.release)
+	assert.NoError(t, f.Close())
+}
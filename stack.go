@@ -0,0 +1,221 @@
+package cilog
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStackDepth : the max frames of a stack if the depth is not set
+const DefaultStackDepth = 32
+
+// Frame : a frame of the stack of a record
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String : "github.com/castisdev/cilog.Test(/src/cilog/stack_test.go:12)"
+func (f Frame) String() string {
+	return string(f.appendTo(nil))
+}
+
+func (f Frame) appendTo(buf []byte) []byte {
+	buf = append(buf, f.Function...)
+	buf = append(buf, '(')
+	buf = append(buf, f.File...)
+	buf = append(buf, ':')
+	buf = strconv.AppendInt(buf, int64(f.Line), 10)
+	return append(buf, ')')
+}
+
+// parseFrame : the reverse of Frame.String
+func parseFrame(s string) (Frame, bool) {
+	i := strings.LastIndexByte(s, '(')
+	j := strings.LastIndexByte(s, ':')
+	if i <= 0 || j <= i || !strings.HasSuffix(s, ")") {
+		return Frame{}, false
+	}
+	line, err := strconv.Atoi(s[j+1 : len(s)-1])
+	if err != nil {
+		return Frame{}, false
+	}
+	return Frame{Function: s[:i], File: s[i+1 : j], Line: line}, true
+}
+
+// stackPrefix : a frame is written as a continuation line of the record in CSV, "\tat github.com/castisdev/cilog.Test(/src/stack_test.go:12)"
+const stackPrefix = "\tat "
+
+// stackOption : the stack trace of a Logger
+type stackOption struct {
+	minLevel Level
+	depth    int
+	excludes []string
+}
+
+// SetStackTrace : records of minLevel or above have the stack of the caller of depth frames at most,
+// frames of the packages of excludes and their sub packages are skipped, e.g. "runtime", "net/http".
+// minLevel 0 disables the stack trace, DefaultStackDepth if depth is 0
+func (l *Logger) SetStackTrace(minLevel Level, depth int, excludes ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stack = stackOption{minLevel: minLevel, depth: depth, excludes: append([]string(nil), excludes...)}
+}
+
+// SetStackTrace :
+func SetStackTrace(minLevel Level, depth int, excludes ...string) {
+	std.SetStackTrace(minLevel, depth, excludes...)
+}
+
+func (o stackOption) enabled(lvl Level) bool {
+	return o.minLevel != 0 && lvl.Rank() >= o.minLevel.Rank()
+}
+
+// frames : the frames of pcs without the excluded packages, depth frames at most
+func (o stackOption) frames(pcs []uintptr) []Frame {
+	depth := o.depth
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	var stack []Frame
+	frames := runtime.CallersFrames(pcs)
+	for len(stack) < depth {
+		f, more := frames.Next()
+		if f.Function != "" && !o.excluded(f.Function) {
+			stack = append(stack, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+func (o stackOption) excluded(function string) bool {
+	if len(o.excludes) == 0 {
+		return false
+	}
+	pkg := functionPackage(function)
+	for _, ex := range o.excludes {
+		if pkg == ex || strings.HasPrefix(pkg, ex+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// functionPackage : "github.com/castisdev/cilog.(*Logger).Log" is "github.com/castisdev/cilog"
+func functionPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// callers : the stack from the frame of skip, 0 is the caller of callers
+func (o stackOption) callers(skip int) []uintptr {
+	depth := o.depth
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	// more frames than depth, some of them may be excluded
+	pcs := make([]uintptr, depth+len(o.excludes)*8)
+	return pcs[:runtime.Callers(skip+2, pcs)]
+}
+
+// stackError : an error with the stack of WithStack
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+// Callers : the stack of the error, the return addresses of runtime.Callers
+func (e *stackError) Callers() []uintptr {
+	return e.pcs
+}
+
+// WithStack : returns err with the stack of the caller, nil if err is nil.
+// err is returned if it already has a stack
+func WithStack(err error) error {
+	if err == nil || errorStack(err) != nil {
+		return err
+	}
+	pcs := make([]uintptr, DefaultStackDepth)
+	return &stackError{err: err, pcs: pcs[:runtime.Callers(2, pcs)]}
+}
+
+// errorStack : the stack of the innermost error of the tree of err which has a stack,
+// by Callers() []uintptr or StackTrace() of github.com/pkg/errors which is a slice of uintptr.
+// the tree is walked in the order of errors.As, Unwrap() []error of errors.Join is walked until a stack is found
+func errorStack(err error) []uintptr {
+	if err == nil {
+		return nil
+	}
+	var inner []uintptr
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		inner = errorStack(u.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			if inner = errorStack(e); inner != nil {
+				break
+			}
+		}
+	}
+	if inner != nil {
+		return inner
+	}
+	if s, ok := err.(interface{ Callers() []uintptr }); ok {
+		return s.Callers()
+	}
+	return reflectStackTrace(err)
+}
+
+func reflectStackTrace(err error) []uintptr {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	if t := m.Type().Out(0); t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	v := m.Call(nil)[0]
+	pcs := make([]uintptr, v.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(v.Index(i).Uint())
+	}
+	return pcs
+}
+
+// LogErr : logs msg and err with the stack of err, or of the caller if err has no stack and lvl has the stack trace.
+// the message is "msg: err", or "err" if msg is empty
+func (l *Logger) LogErr(calldepth int, lvl Level, err error, msg string, t time.Time) {
+	l.log(nil, calldepth+1, lvl, message{format: msg, raw: true, withErr: true}, nil, nil, t, err)
+}
+
+// ErrorErr : logs err with the message of format, LogErr of ERROR
+func ErrorErr(err error, format string, v ...interface{}) {
+	std.log(nil, 2, ERROR, message{format: format, withErr: true}, v, nil, time.Time{}, err)
+}
+
+// ExceptionErr :
+func ExceptionErr(err error, format string, v ...interface{}) {
+	std.log(nil, 2, EXCEPTION, message{format: format, withErr: true}, v, nil, time.Time{}, err)
+}
+
+// CriticalErr :
+func CriticalErr(err error, format string, v ...interface{}) {
+	std.log(nil, 2, CRITICAL, message{format: format, withErr: true}, v, nil, time.Time{}, err)
+}
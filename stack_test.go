@@ -0,0 +1,151 @@
+package cilog_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_SetStackTrace(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetStackTrace(cilog.EXCEPTION, 0)
+
+	logger.Log(1, cilog.ERROR, "error", time.Now())
+	assert.Equal(t, 1, strings.Count(w.writed, "\n"), w.writed)
+
+	w.writed = ""
+	logger.Log(1, cilog.EXCEPTION, "exception", time.Now())
+	_, file, line, _ := runtime.Caller(0)
+	lines := strings.Split(strings.TrimSuffix(w.writed, "\n"), "\n")
+	if assert.True(t, len(lines) > 2, w.writed) {
+		assert.True(t, strings.HasSuffix(lines[0], ",,exception"), lines[0])
+		assert.Equal(t, "\tat github.com/castisdev/cilog_test.TestLogger_SetStackTrace("+file+":"+strconv.Itoa(line-1)+")", lines[1])
+		assert.True(t, strings.HasPrefix(lines[2], "\tat testing.tRunner("), lines[2])
+	}
+
+	r := cilog.NewReader(strings.NewReader(w.writed))
+	if assert.True(t, r.Next()) {
+		rec := r.Record()
+		assert.Equal(t, "exception", rec.Message)
+		if assert.Equal(t, len(lines)-1, len(rec.Stack)) {
+			assert.Equal(t, cilog.Frame{Function: "github.com/castisdev/cilog_test.TestLogger_SetStackTrace", File: file, Line: line - 1}, rec.Stack[0])
+		}
+	}
+	assert.False(t, r.Next())
+
+	w.writed = ""
+	logger.SetStackTrace(cilog.EXCEPTION, 0, "testing", "runtime")
+	logger.Log(1, cilog.CRITICAL, "critical", time.Now())
+	assert.Equal(t, 2, strings.Count(w.writed, "\n"), w.writed)
+
+	w.writed = ""
+	logger.SetStackTrace(cilog.ERROR, 1)
+	logger.Log(1, cilog.ERROR, "error", time.Now())
+	assert.Equal(t, 2, strings.Count(w.writed, "\n"), w.writed)
+
+	w.writed = ""
+	logger.SetStackTrace(0, 0)
+	logger.Log(1, cilog.CRITICAL, "critical", time.Now())
+	assert.Equal(t, 1, strings.Count(w.writed, "\n"), w.writed)
+}
+
+func newStackError() (error, int) {
+	_, _, line, _ := runtime.Caller(0)
+	return cilog.WithStack(errors.New("failed")), line + 1
+}
+
+type pkgFrame uintptr
+
+type pkgError struct {
+	pcs []pkgFrame
+}
+
+func (e *pkgError) Error() string {
+	return "pkg error"
+}
+
+func (e *pkgError) StackTrace() []pkgFrame {
+	return e.pcs
+}
+
+func newPkgError() (error, int) {
+	pcs := make([]uintptr, 8)
+	_, _, line, _ := runtime.Caller(0)
+	n := runtime.Callers(1, pcs)
+	e := &pkgError{}
+	for _, pc := range pcs[:n] {
+		e.pcs = append(e.pcs, pkgFrame(pc))
+	}
+	return e, line + 1
+}
+
+func TestLogger_LogErr(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+
+	logger.LogErr(1, cilog.ERROR, errors.New("failed"), "open", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",,open: failed\n"), w.writed)
+
+	errs := []func() (error, int){newStackError, newPkgError}
+	for i, f := range errs {
+		err, line := f()
+		name := []string{"newStackError", "newPkgError"}[i]
+		w.writed = ""
+		logger.LogErr(1, cilog.ERROR, fmt.Errorf("wrapped: %w", err), "", time.Now())
+		r := cilog.NewReader(strings.NewReader(w.writed))
+		if assert.True(t, r.Next()) {
+			rec := r.Record()
+			assert.True(t, strings.HasPrefix(rec.Message, "wrapped: "), rec.Message)
+			if assert.True(t, len(rec.Stack) > 1, w.writed) {
+				assert.Equal(t, "github.com/castisdev/cilog_test."+name, rec.Stack[0].Function)
+				assert.Equal(t, line, rec.Stack[0].Line)
+				assert.Equal(t, "github.com/castisdev/cilog_test.TestLogger_LogErr", rec.Stack[1].Function)
+			}
+		}
+	}
+
+	stackErr, line := newStackError()
+	joined := []error{
+		errors.Join(errors.New("first"), fmt.Errorf("second: %w", stackErr)),
+		fmt.Errorf("wrapped: %w", errors.Join(errors.New("first"), stackErr)),
+		fmt.Errorf("multi: %w, %w", errors.New("first"), stackErr),
+	}
+	for _, err := range joined {
+		w.writed = ""
+		logger.LogErr(1, cilog.ERROR, err, "", time.Now())
+		r := cilog.NewReader(strings.NewReader(w.writed))
+		if assert.True(t, r.Next()) && assert.True(t, len(r.Record().Stack) > 1, w.writed) {
+			assert.Equal(t, "github.com/castisdev/cilog_test.newStackError", r.Record().Stack[0].Function)
+			assert.Equal(t, line, r.Record().Stack[0].Line)
+		}
+		assert.Equal(t, err, cilog.WithStack(err))
+	}
+
+	err, _ := newStackError()
+	assert.Equal(t, err, cilog.WithStack(err))
+	assert.Nil(t, cilog.WithStack(nil))
+	assert.Equal(t, "failed", err.Error())
+}
+
+func TestJSONEncoder_Stack(t *testing.T) {
+	r := cilog.Record{
+		Module:  "module",
+		Time:    time.Now(),
+		Level:   cilog.EXCEPTION,
+		Message: "abc",
+		Stack:   []cilog.Frame{{Function: "main.main", File: "/src/main.go", Line: 10}, {Function: "runtime.main", File: "/go/proc.go", Line: 250}},
+	}
+	line := string(cilog.JSONEncoder{}.Encode(nil, &r))
+	assert.Contains(t, line, `,"stack":["main.main(/src/main.go:10)","runtime.main(/go/proc.go:250)"]}`)
+	parsed, err := cilog.ParseLine(line)
+	assert.NoError(t, err)
+	assert.Equal(t, r.Stack, parsed.Stack)
+}
@@ -0,0 +1,63 @@
+package cilog
+
+import (
+	"os"
+	"sort"
+	"strconv"
+)
+
+// HostnameField : "host=hostname", the hostname is empty if it is unknown
+func HostnameField() Field {
+	host, _ := os.Hostname()
+	return Field{Key: "host", Value: host}
+}
+
+// PIDField : "pid=1234"
+func PIDField() Field {
+	return Field{Key: "pid", Value: strconv.Itoa(os.Getpid())}
+}
+
+// SetStaticFields : fields written in every record before the fields of the context, e.g. HostnameField(), PIDField(),
+// {"dc", "kr1"}. records have the default format if there is no field
+func (l *Logger) SetStaticFields(fields ...Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.staticFields = append(Fields(nil), fields...)
+}
+
+// GetStaticFields :
+func (l *Logger) GetStaticFields() Fields {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append(Fields(nil), l.staticFields...)
+}
+
+// SetStaticFields :
+func SetStaticFields(fields ...Field) {
+	std.SetStaticFields(fields...)
+}
+
+// GetStaticFields :
+func GetStaticFields() Fields {
+	return std.GetStaticFields()
+}
+
+// staticFields : the static fields of Config, host, pid and the others sorted by key
+func (c Config) staticFields() Fields {
+	var fs Fields
+	if c.Hostname {
+		fs = append(fs, HostnameField())
+	}
+	if c.PID {
+		fs = append(fs, PIDField())
+	}
+	keys := make([]string, 0, len(c.StaticFields))
+	for k := range c.StaticFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fs = append(fs, Field{Key: k, Value: os.ExpandEnv(c.StaticFields[k])})
+	}
+	return fs
+}
@@ -0,0 +1,77 @@
+package cilog_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_SetStaticFields(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetStaticFields(cilog.PIDField(), cilog.Field{Key: "dc", Value: "kr1"})
+	pid := strconv.Itoa(os.Getpid())
+	assert.Equal(t, cilog.Fields{{Key: "pid", Value: pid}, {Key: "dc", Value: "kr1"}}, logger.GetStaticFields())
+
+	logger.LogContext(cilog.WithRequestID(context.Background(), "req1"), 1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",pid="+pid+" dc=kr1 request_id=req1,abc\n"), w.writed)
+	r, err := cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	v, _ := r.Fields.Get("dc")
+	assert.Equal(t, "kr1", v)
+
+	w.writed = ""
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",pid="+pid+" dc=kr1,abc\n"), w.writed)
+
+	w.writed = ""
+	logger.SetEncoder(cilog.JSONEncoder{})
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.Contains(t, w.writed, `,"fields":{"pid":"`+pid+`","dc":"kr1"},`)
+	r, err = cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	assert.Equal(t, logger.GetStaticFields(), r.Fields)
+
+	w.writed = ""
+	logger.SetEncoder(nil)
+	logger.SetStaticFields()
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",,abc\n"), w.writed)
+}
+
+func TestHostnameField(t *testing.T) {
+	host, _ := os.Hostname()
+	assert.Equal(t, cilog.Field{Key: "host", Value: host}, cilog.HostnameField())
+}
+
+func TestConfig_StaticFields(t *testing.T) {
+	os.Setenv("CILOG_TEST_INSTANCE", "i-1")
+	defer os.Unsetenv("CILOG_TEST_INSTANCE")
+	c, err := cilog.ParseConfig([]byte(`
+writer: stdout
+module: example
+pid: true
+staticFields:
+  instance: ${CILOG_TEST_INSTANCE}
+  dc: kr1
+`), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := cilog.Fields{
+		{Key: "pid", Value: strconv.Itoa(os.Getpid())},
+		{Key: "dc", Value: "kr1"},
+		{Key: "instance", Value: "i-1"},
+	}
+	assert.Equal(t, expected, logger.GetStaticFields())
+}
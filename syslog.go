@@ -0,0 +1,256 @@
+package cilog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Facility : syslog facility
+type Facility int
+
+// Facility enum
+const (
+	KERN Facility = iota
+	USER
+	MAIL
+	DAEMON
+	AUTH
+	SYSLOG
+	LPR
+	NEWS
+	UUCP
+	CRON
+	AUTHPRIV
+	FTP
+	_
+	_
+	_
+	_
+	LOCAL0
+	LOCAL1
+	LOCAL2
+	LOCAL3
+	LOCAL4
+	LOCAL5
+	LOCAL6
+	LOCAL7
+)
+
+// syslog severities
+const (
+	sevAlert   = 1
+	sevCrit    = 2
+	sevErr     = 3
+	sevWarning = 4
+	sevNotice  = 5
+	sevInfo    = 6
+	sevDebug   = 7
+)
+
+// SyslogSeverity : the syslog severity of lvl, levels added with RegisterLevel have the severity of the level below them.
+// a more severe level never has a less severe syslog severity
+//
+//	ALERT                         alert(1)
+//	EXCEPTION, CRITICAL           crit(2)
+//	ERROR, FAIL                   err(3)
+//	WARNING                       warning(4)
+//	NOTICE, SUCCESS               notice(5)
+//	INFO                          info(6)
+//	TRACE, DEBUG, REPORT          debug(7)
+func SyslogSeverity(lvl Level) int {
+	r := lvl.Rank()
+	switch {
+	case r >= ALERT.Rank():
+		return sevAlert
+	case r >= EXCEPTION.Rank():
+		return sevCrit
+	case r >= ERROR.Rank():
+		return sevErr
+	case r >= WARNING.Rank():
+		return sevWarning
+	case r >= NOTICE.Rank():
+		return sevNotice
+	case r >= INFO.Rank():
+		return sevInfo
+	default:
+		return sevDebug
+	}
+}
+
+// SyslogFormat :
+type SyslogFormat int
+
+// SyslogFormat enum
+const (
+	RFC5424 SyslogFormat = iota
+	RFC3164
+)
+
+// SyslogWriter : sends logs written by Logger to a syslog server.
+// every line is parsed with ParseLine to get its level. the lines which are not records (the stack and the lines of
+// a multi-line message) belong to the record before them like Reader, the lines before any record are sent as INFO.
+type SyslogWriter struct {
+	mu       sync.Mutex
+	network  string
+	addr     string
+	facility Facility
+	format   SyslogFormat
+	appName  string
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogWriter : network is "udp", "tcp", "unix" or "unixgram".
+// appName is the APP-NAME (TAG of RFC3164), the module of each record if empty.
+// the connection is made on the first write, and made again if a write fails.
+func NewSyslogWriter(network string, addr string, facility Facility, appName string) *SyslogWriter {
+	host, _ := os.Hostname()
+	return &SyslogWriter{network: network, addr: addr, facility: facility, appName: appName, hostname: host}
+}
+
+// SetFormat : RFC5424 by default
+func (w *SyslogWriter) SetFormat(f SyslogFormat) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.format = f
+}
+
+// Write :
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	var cur *Record
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		if r, err := ParseLine(line); err == nil {
+			if cur != nil {
+				if err := w.WriteRecord(cur); err != nil {
+					return 0, err
+				}
+			}
+			cur = &r
+			continue
+		}
+		if cur == nil {
+			cur = &Record{Time: time.Now(), Level: INFO, Message: line}
+			continue
+		}
+		if strings.HasPrefix(line, stackPrefix) {
+			if f, ok := parseFrame(line[len(stackPrefix):]); ok {
+				cur.Stack = append(cur.Stack, f)
+				continue
+			}
+		}
+		cur.Message += "\n" + line
+	}
+	if cur != nil {
+		if err := w.WriteRecord(cur); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// WriteRecord :
+func (w *SyslogWriter) WriteRecord(r *Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msg := w.message(r)
+	if err := w.send(msg); err != nil {
+		// reconnect once
+		w.close()
+		return w.send(msg)
+	}
+	return nil
+}
+
+func (w *SyslogWriter) message(r *Record) []byte {
+	pri := int(w.facility)*8 + SyslogSeverity(r.Level)
+	app := w.appName
+	if app == "" {
+		app = r.Module
+	}
+	if app == "" {
+		app = "-"
+	}
+	host := w.hostname
+	if host == "" {
+		host = "-"
+	}
+	msg := r.Message
+	if len(r.Fields) > 0 {
+		msg = r.Fields.String() + " " + msg
+	}
+	if len(r.Stack) > 0 {
+		// a line per message, the frames are an escaped field
+		frames := make([]string, len(r.Stack))
+		for i, f := range r.Stack {
+			frames[i] = f.String()
+		}
+		msg = strings.TrimRight(msg, "\n") + " stack=[" + strings.Join(frames, "; ") + "]"
+	}
+	if caller := appendCaller(nil, r); len(caller) > 0 {
+		msg = string(caller) + " " + msg
+	}
+
+	var m string
+	if w.format == RFC3164 {
+		m = fmt.Sprintf("<%d>%s %s %s[%d]: %s", pri, r.Time.Format(time.Stamp), host, app, os.Getpid(), msg)
+	} else {
+		m = fmt.Sprintf("<%d>1 %s %s %s %d - - %s", pri, r.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+			host, app, os.Getpid(), msg)
+	}
+	if w.network == "tcp" || w.network == "unix" {
+		// stream transports need framing (RFC 6587)
+		if w.format == RFC3164 {
+			return []byte(m + "\n")
+		}
+		return []byte(strconv.Itoa(len(m)) + " " + m)
+	}
+	return []byte(m)
+}
+
+func (w *SyslogWriter) send(msg []byte) error {
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.addr, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+func (w *SyslogWriter) close() error {
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// Close :
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.close()
+}
+
+// FacilityFromString : "user", "daemon", "local0" ...
+func FacilityFromString(s string) (Facility, error) {
+	m := map[string]Facility{
+		"kern": KERN, "user": USER, "mail": MAIL, "daemon": DAEMON, "auth": AUTH, "syslog": SYSLOG,
+		"lpr": LPR, "news": NEWS, "uucp": UUCP, "cron": CRON, "authpriv": AUTHPRIV, "ftp": FTP,
+		"local0": LOCAL0, "local1": LOCAL1, "local2": LOCAL2, "local3": LOCAL3,
+		"local4": LOCAL4, "local5": LOCAL5, "local6": LOCAL6, "local7": LOCAL7,
+	}
+	f, ok := m[strings.ToLower(s)]
+	if !ok {
+		return USER, fmt.Errorf("invalid syslog facility [%s]", s)
+	}
+	return f, nil
+}
@@ -0,0 +1,257 @@
+package cilog_test
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level    cilog.Level
+		severity int
+	}{
+		{cilog.TRACE, 7},
+		{cilog.DEBUG, 7},
+		{cilog.REPORT, 7},
+		{cilog.INFO, 6},
+		{cilog.NOTICE, 5},
+		{cilog.SUCCESS, 5},
+		{cilog.WARNING, 4},
+		{cilog.ERROR, 3},
+		{cilog.FAIL, 3},
+		{cilog.EXCEPTION, 2},
+		{cilog.CRITICAL, 2},
+		{cilog.ALERT, 1},
+	}
+	for i, tt := range tests {
+		assert.Equal(t, tt.severity, cilog.SyslogSeverity(tt.level), "%s", tt.level)
+		// the levels are in order, so the severities never become less severe
+		if i > 0 {
+			assert.True(t, tt.severity <= tests[i-1].severity, "%s", tt.level)
+		}
+	}
+}
+
+func TestSyslogWriter_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w := cilog.NewSyslogWriter("udp", pc.LocalAddr().String(), cilog.LOCAL0, "")
+	defer w.Close()
+	tm := time.Date(2009, 11, 23, 15, 21, 30, 123456000, time.UTC)
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.Log(1, cilog.ERROR, "abc", tm)
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, _ := os.Hostname()
+	expected := "<131>1 2009-11-23T15:21:30.123456Z " + host + " module " + strconv.Itoa(os.Getpid()) +
+		" - - cilog_test::syslog_test.go:"
+	assert.True(t, strings.HasPrefix(string(buf[:n]), expected), string(buf[:n]))
+	assert.True(t, strings.HasSuffix(string(buf[:n]), " abc"), string(buf[:n]))
+}
+
+func TestSyslogWriter_MultiLine(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w := cilog.NewSyslogWriter("udp", pc.LocalAddr().String(), cilog.LOCAL0, "")
+	defer w.Close()
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetStackTrace(cilog.CRITICAL, 0)
+	logger.Log(1, cilog.CRITICAL, "first\nsecond", time.Now())
+	logger.Log(1, cilog.INFO, "next", time.Now())
+
+	buf := make([]byte, 4096)
+	var msgs []string
+	for i := 0; i < 2; i++ {
+		pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, string(buf[:n]))
+	}
+	// a datagram per record, the lines of the message and the stack are in it
+	assert.True(t, strings.HasPrefix(msgs[0], "<130>1 "), msgs[0])
+	assert.Contains(t, msgs[0], "first\nsecond stack=[github.com/castisdev/cilog_test.TestSyslogWriter_MultiLine(")
+	assert.True(t, strings.HasPrefix(msgs[1], "<134>1 "), msgs[1])
+	assert.True(t, strings.HasSuffix(msgs[1], " next"), msgs[1])
+
+	// the lines before any record are a message
+	n, err := w.Write([]byte("plain\ntext\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 11, n)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err = pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(string(buf[:n]), "<134>1 "), string(buf[:n]))
+	assert.True(t, strings.HasSuffix(string(buf[:n]), " plain\ntext"), string(buf[:n]))
+}
+
+func TestSyslogWriter_Unixgram(t *testing.T) {
+	dir := t.TempDir()
+	addr := filepath.Join(dir, "syslog.sock")
+	pc, err := net.ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w := cilog.NewSyslogWriter("unixgram", addr, cilog.USER, "app")
+	defer w.Close()
+	w.SetFormat(cilog.RFC3164)
+	w.Write([]byte("not a cilog line\n"))
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<14>"), msg)
+	assert.True(t, strings.HasSuffix(msg, " app["+strconv.Itoa(os.Getpid())+"]: not a cilog line"), msg)
+}
+
+func TestSyslogWriter_TCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+
+	w := cilog.NewSyslogWriter("tcp", ln.Addr().String(), cilog.DAEMON, "app")
+	defer w.Close()
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.Log(1, cilog.WARNING, "first", time.Now())
+
+	c1 := <-conns
+	r := bufio.NewReader(c1)
+	size, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(size))
+	msg := make([]byte, n)
+	if _, err := r.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(string(msg), "<28>1 "), string(msg))
+	assert.True(t, strings.HasSuffix(string(msg), " first"), string(msg))
+
+	// the server closes the connection, the writer connects again
+	c1.Close()
+	for i := 0; i < 100; i++ {
+		logger.Log(1, cilog.WARNING, "second", time.Now())
+		select {
+		case c2 := <-conns:
+			c2.Close()
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Error("syslog writer does not reconnect")
+}
+
+func TestConfig_ValidateSyslog(t *testing.T) {
+	valid := cilog.Config{Writer: "stderr", Module: "module",
+		Sinks: []cilog.SinkConfig{{Writer: "syslog", Network: "udp", Address: "127.0.0.1:514", Facility: "local0"}}}
+	assert.NoError(t, valid.Validate())
+
+	for _, s := range []cilog.SinkConfig{
+		{Writer: "syslog", Network: "http", Address: "127.0.0.1:514"},
+		{Writer: "syslog", Network: "udp"},
+		{Writer: "syslog", Network: "udp", Address: "127.0.0.1:514", Facility: "local9"},
+		{Writer: "syslog", Network: "udp", Address: "127.0.0.1:514", Format: "json"},
+		// a synchronous network writer blocks the other writers while it dials
+		{Writer: "syslog", Network: "tcp", Address: "127.0.0.1:514", QueueSize: -1},
+		{Writer: "syslog", Network: "udp", Address: "127.0.0.1:514", QueueSize: -2},
+	} {
+		c := cilog.Config{Writer: "stderr", Module: "module", Sinks: []cilog.SinkConfig{s}}
+		assert.Error(t, c.Validate(), "%v", s)
+	}
+}
+
+func TestConfig_SyslogQueue(t *testing.T) {
+	// a server which accepts but never reads, the writes to it block once the socket buffers are full
+	dir := t.TempDir()
+	ln, err := net.Listen("unix", filepath.Join(dir, "syslog.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make(chan net.Conn, 10)
+	go func() {
+		defer close(conns)
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+
+	c := cilog.Config{Dir: filepath.Join(dir, "log"), Module: "module", MinLevel: cilog.INFO,
+		Sinks: []cilog.SinkConfig{{Writer: "syslog", Network: "unix", Address: ln.Addr().String()}}}
+	logger, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := logger.GetSink().(*cilog.FanoutSink)
+
+	msg := strings.Repeat("a", 4*1024)
+	done := make(chan struct{})
+	go func() {
+		// the default queue of the sink drops the records the server does not take
+		for i := 0; i < 2000; i++ {
+			logger.Log(1, cilog.INFO, msg, time.Now())
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		assert.True(t, f.Dropped()[1] > 0, "dropped %v", f.Dropped())
+	case <-time.After(5 * time.Second):
+		t.Error("the syslog sink blocks logging")
+	}
+
+	// the server goes away, so that the sink finishes
+	ln.Close()
+	for c := range conns {
+		c.Close()
+	}
+	<-done
+	logger.Close()
+}
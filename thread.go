@@ -0,0 +1,63 @@
+package cilog
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// GoroutineID : the id of the calling goroutine, 0 if it is unknown.
+// it is parsed from the header of runtime.Stack, "goroutine 18 [running]:".
+// runtime.Stack unwinds the whole stack of the goroutine even though only the header is read,
+// so it costs microseconds growing with the depth of the stack, several times a Log call (see BenchmarkGoroutineID)
+func GoroutineID() uint64 {
+	// "goroutine " and the digits of uint64 fit in buf
+	var buf [32]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	const prefix = "goroutine "
+	if len(b) <= len(prefix) || string(b[:len(prefix)]) != prefix {
+		return 0
+	}
+	var id uint64
+	for _, c := range b[len(prefix):] {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
+
+func goroutineIDString() string {
+	return strconv.FormatUint(GoroutineID(), 10)
+}
+
+// SetThreadID : the column between the caller and the message begins with f(), e.g. a worker id.
+// the column is empty if f is nil
+func (l *Logger) SetThreadID(f func() string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.threadID = f
+}
+
+// SetGoroutineID : the column between the caller and the message begins with the goroutine id, like the thread id of cilog for C++.
+// it is not for hot paths: GoroutineID is called for every record written, and it costs several times a Log call
+// (see BenchmarkLogger_GoroutineID). it is meant for debugging and low volume logs.
+// for hot paths, use SetThreadID with an id the caller already has, e.g. a worker id kept by the worker
+// (see BenchmarkLogger_ThreadID)
+func (l *Logger) SetGoroutineID(enabled bool) {
+	if enabled {
+		l.SetThreadID(goroutineIDString)
+	} else {
+		l.SetThreadID(nil)
+	}
+}
+
+// SetThreadID :
+func SetThreadID(f func() string) {
+	std.SetThreadID(f)
+}
+
+// SetGoroutineID : not for hot paths, see Logger.SetGoroutineID
+func SetGoroutineID(enabled bool) {
+	std.SetGoroutineID(enabled)
+}
@@ -0,0 +1,122 @@
+package cilog_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineID(t *testing.T) {
+	id := cilog.GoroutineID()
+	assert.NotEqual(t, uint64(0), id)
+	assert.Equal(t, id, cilog.GoroutineID())
+
+	ch := make(chan uint64)
+	go func() { ch <- cilog.GoroutineID() }()
+	other := <-ch
+	assert.NotEqual(t, uint64(0), other)
+	assert.NotEqual(t, id, other)
+}
+
+func TestLogger_SetGoroutineID(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	logger.SetGoroutineID(true)
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	id := strconv.FormatUint(cilog.GoroutineID(), 10)
+	assert.True(t, strings.HasSuffix(w.writed, ","+id+",abc\n"), w.writed)
+
+	r, err := cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	assert.Equal(t, id, r.ThreadID)
+	assert.Nil(t, r.Fields)
+
+	w.writed = ""
+	logger.LogContext(cilog.WithRequestID(context.Background(), "req1"), 1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ","+id+" request_id=req1,abc\n"), w.writed)
+	r, err = cilog.ParseLine(w.writed)
+	assert.NoError(t, err)
+	assert.Equal(t, id, r.ThreadID)
+	assert.Equal(t, cilog.Fields{{Key: "request_id", Value: "req1"}}, r.Fields)
+
+	w.writed = ""
+	logger.SetGoroutineID(false)
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.True(t, strings.HasSuffix(w.writed, ",,abc\n"), w.writed)
+}
+
+func TestLogger_SetThreadID(t *testing.T) {
+	w := &syncStringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	var mu sync.Mutex
+	workers := map[uint64]string{}
+	logger.SetThreadID(func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return workers[cilog.GoroutineID()]
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mu.Lock()
+			workers[cilog.GoroutineID()] = "worker" + strconv.Itoa(i)
+			mu.Unlock()
+			logger.Log(1, cilog.INFO, "job"+strconv.Itoa(i), time.Now())
+		}(i)
+	}
+	wg.Wait()
+
+	r := cilog.NewReader(strings.NewReader(w.String()))
+	n := 0
+	for r.Next() {
+		rec := r.Record()
+		assert.Equal(t, "worker"+strings.TrimPrefix(rec.Message, "job"), rec.ThreadID)
+		n++
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestJSONEncoder_ThreadID(t *testing.T) {
+	r := cilog.Record{Module: "module", Time: time.Now(), Level: cilog.INFO, ThreadID: "18", Message: "abc"}
+	line := string(cilog.JSONEncoder{}.Encode(nil, &r))
+	assert.Contains(t, line, `,"thread":"18",`)
+	parsed, err := cilog.ParseLine(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "18", parsed.ThreadID)
+}
+
+func BenchmarkGoroutineID(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		cilog.GoroutineID()
+	}
+}
+
+func BenchmarkLogger_GoroutineID(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.DEBUG)
+	logger.SetGoroutineID(true)
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log(1, cilog.INFO, "abc", now)
+	}
+}
+
+func BenchmarkLogger_ThreadID(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.DEBUG)
+	// an id cached by the worker instead of the goroutine id
+	logger.SetThreadID(func() string { return "worker1" })
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log(1, cilog.INFO, "abc", now)
+	}
+}
@@ -0,0 +1,180 @@
+package cilog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayout :
+type TimeLayout int
+
+// time layouts of encoders
+const (
+	// LayoutDefault : "2009-11-23,15:21:30.123456" in CSV, ISO-8601 in JSON
+	LayoutDefault TimeLayout = iota
+	// LayoutISO8601 : "2009-11-23T15:21:30.123456+09:00", a column in CSV
+	LayoutISO8601
+	// LayoutEpoch : seconds since the Unix epoch, "1258957290.123456", a column in CSV and a number in JSON
+	LayoutEpoch
+)
+
+// TimePrecision : digits of the fractional second
+type TimePrecision int
+
+// time precisions of encoders
+const (
+	PrecisionMicro TimePrecision = iota
+	PrecisionMilli
+	PrecisionNano
+)
+
+// TimeFormat : the time format of CSVEncoder and JSONEncoder, the zero value is the format of Logger.Log
+type TimeFormat struct {
+	Layout    TimeLayout
+	Precision TimePrecision
+	// Location : the time is written in its own location if nil, e.g. time.UTC
+	Location *time.Location
+}
+
+func (f TimeFormat) digits() int {
+	switch f.Precision {
+	case PrecisionMilli:
+		return 3
+	case PrecisionNano:
+		return 9
+	}
+	return 6
+}
+
+// the layouts are constants, so that formatting a time does not build the layout
+func (f TimeFormat) csvLayout() string {
+	switch f.Precision {
+	case PrecisionMilli:
+		return "2006-01-02,15:04:05.000"
+	case PrecisionNano:
+		return "2006-01-02,15:04:05.000000000"
+	}
+	return "2006-01-02,15:04:05.000000"
+}
+
+func (f TimeFormat) isoLayout() string {
+	switch f.Precision {
+	case PrecisionMilli:
+		return "2006-01-02T15:04:05.000Z07:00"
+	case PrecisionNano:
+		return "2006-01-02T15:04:05.000000000Z07:00"
+	}
+	return "2006-01-02T15:04:05.000000Z07:00"
+}
+
+// appendCSV : "2009-11-23,15:21:30.123456", or a column of ISO-8601 or epoch
+func (f TimeFormat) appendCSV(buf []byte, t time.Time) []byte {
+	if f.Location != nil {
+		t = t.In(f.Location)
+	}
+	switch f.Layout {
+	case LayoutISO8601:
+		return t.AppendFormat(buf, f.isoLayout())
+	case LayoutEpoch:
+		return f.appendEpoch(buf, t)
+	}
+	return t.AppendFormat(buf, f.csvLayout())
+}
+
+// appendJSON : a string of ISO-8601, or a number of epoch
+func (f TimeFormat) appendJSON(buf []byte, t time.Time) []byte {
+	if f.Location != nil {
+		t = t.In(f.Location)
+	}
+	if f.Layout == LayoutEpoch {
+		return f.appendEpoch(buf, t)
+	}
+	buf = append(buf, '"')
+	buf = t.AppendFormat(buf, f.isoLayout())
+	return append(buf, '"')
+}
+
+func (f TimeFormat) appendEpoch(buf []byte, t time.Time) []byte {
+	buf = strconv.AppendInt(buf, t.Unix(), 10)
+	buf = append(buf, '.')
+	// the nanoseconds of 9 digits, the last digits are cut by the precision
+	var frac [9]byte
+	ns := t.Nanosecond()
+	for i := len(frac) - 1; i >= 0; i-- {
+		frac[i] = byte('0' + ns%10)
+		ns /= 10
+	}
+	return append(buf, frac[:f.digits()]...)
+}
+
+// parseTime : parses a time written with any TimeFormat, the time without a zone is parsed in loc
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	if strings.IndexByte(s, '-') == -1 {
+		return parseEpoch(s)
+	}
+	if strings.IndexByte(s, 'T') >= 0 {
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	// the fractional second of any digits is accepted after the seconds
+	return time.ParseInLocation("2006-01-02,15:04:05", s, loc)
+}
+
+// parseEpoch : "1258957290.123456"
+func parseEpoch(s string) (time.Time, error) {
+	sec, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		sec, frac = s[:i], s[i+1:]
+	}
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil || len(frac) > 9 {
+		return time.Time{}, fmt.Errorf("invalid epoch time [%s]", s)
+	}
+	var nsec int64
+	if frac != "" {
+		if nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch time [%s]", s)
+		}
+	}
+	return time.Unix(n, nsec), nil
+}
+
+// TimeLayoutFromString : "default", "iso8601" or "epoch"
+func TimeLayoutFromString(s string) (TimeLayout, error) {
+	switch strings.ToLower(s) {
+	case "", "default":
+		return LayoutDefault, nil
+	case "iso8601", "iso":
+		return LayoutISO8601, nil
+	case "epoch", "unix":
+		return LayoutEpoch, nil
+	}
+	return LayoutDefault, fmt.Errorf("time format [%s] is not supported, use default, iso8601 or epoch", s)
+}
+
+// TimePrecisionFromString : "ms", "us" or "ns"
+func TimePrecisionFromString(s string) (TimePrecision, error) {
+	switch strings.ToLower(s) {
+	case "", "us", "µs", "micro":
+		return PrecisionMicro, nil
+	case "ms", "milli":
+		return PrecisionMilli, nil
+	case "ns", "nano":
+		return PrecisionNano, nil
+	}
+	return PrecisionMicro, fmt.Errorf("time precision [%s] is not supported, use ms, us or ns", s)
+}
+
+// LocationFromString : nil (the location of the time itself) if s is empty, "local", "utc" or a name of the IANA database
+func LocationFromString(s string) (*time.Location, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return nil, nil
+	case "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s)
+}
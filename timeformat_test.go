@@ -0,0 +1,119 @@
+package cilog_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVEncoder_TimeFormat(t *testing.T) {
+	seoul := time.FixedZone("KST", 9*60*60)
+	tm := time.Date(2009, 11, 23, 15, 21, 30, 123456789, seoul)
+	r := cilog.Record{Module: "module", ModuleVer: "1.0", Time: tm, Level: cilog.DEBUG,
+		Package: "package1", File: "src.go", Line: 56, Message: "this is a example"}
+
+	tests := []struct {
+		format   cilog.TimeFormat
+		expected string
+		parsed   time.Time
+	}{
+		{
+			format:   cilog.TimeFormat{},
+			expected: "module,1.0,2009-11-23,15:21:30.123456,Debug,package1::src.go:56,,this is a example\n",
+		},
+		{
+			format:   cilog.TimeFormat{Precision: cilog.PrecisionMilli, Location: time.UTC},
+			expected: "module,1.0,2009-11-23,06:21:30.123,Debug,package1::src.go:56,,this is a example\n",
+			parsed:   time.Date(2009, 11, 23, 6, 21, 30, 123000000, time.UTC),
+		},
+		{
+			format:   cilog.TimeFormat{Layout: cilog.LayoutISO8601, Precision: cilog.PrecisionNano},
+			expected: "module,1.0,2009-11-23T15:21:30.123456789+09:00,Debug,package1::src.go:56,,this is a example\n",
+			parsed:   tm,
+		},
+		{
+			format:   cilog.TimeFormat{Layout: cilog.LayoutISO8601, Location: time.UTC},
+			expected: "module,1.0,2009-11-23T06:21:30.123456Z,Debug,package1::src.go:56,,this is a example\n",
+			parsed:   time.Date(2009, 11, 23, 6, 21, 30, 123456000, time.UTC),
+		},
+		{
+			format:   cilog.TimeFormat{Layout: cilog.LayoutEpoch, Precision: cilog.PrecisionMilli},
+			expected: "module,1.0,1258957290.123,Debug,package1::src.go:56,,this is a example\n",
+			parsed:   time.Date(2009, 11, 23, 6, 21, 30, 123000000, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		line := string(cilog.CSVEncoder{Time: tt.format}.Encode(nil, &r))
+		assert.Equal(t, tt.expected, line)
+		if tt.parsed.IsZero() {
+			continue
+		}
+		parsed, err := cilog.ParseLine(line)
+		if assert.NoError(t, err, line) {
+			assert.True(t, tt.parsed.Equal(parsed.Time), line)
+			assert.Equal(t, cilog.DEBUG, parsed.Level)
+			assert.Equal(t, 56, parsed.Line)
+			assert.Equal(t, "this is a example", parsed.Message)
+		}
+	}
+}
+
+func TestJSONEncoder_TimeFormat(t *testing.T) {
+	tm := time.Date(2009, 11, 23, 6, 21, 30, 123456789, time.UTC)
+	r := cilog.Record{Module: "module", Time: tm, Level: cilog.INFO, Message: "abc"}
+
+	line := string(cilog.JSONEncoder{Time: cilog.TimeFormat{Layout: cilog.LayoutEpoch, Precision: cilog.PrecisionNano}}.Encode(nil, &r))
+	assert.Contains(t, line, `"time":1258957290.123456789,`)
+	parsed, err := cilog.ParseLine(line)
+	assert.NoError(t, err)
+	assert.True(t, tm.Equal(parsed.Time))
+
+	line = string(cilog.JSONEncoder{Time: cilog.TimeFormat{Precision: cilog.PrecisionMilli}}.Encode(nil, &r))
+	assert.Contains(t, line, `"time":"2009-11-23T06:21:30.123Z",`)
+	parsed, err = cilog.ParseLine(line)
+	assert.NoError(t, err)
+	assert.True(t, tm.Truncate(time.Millisecond).Equal(parsed.Time))
+}
+
+func TestReader_TimeFormats(t *testing.T) {
+	tm := time.Date(2009, 11, 23, 6, 21, 30, 0, time.UTC)
+	var buf []byte
+	for _, f := range []cilog.TimeFormat{{}, {Layout: cilog.LayoutISO8601}, {Layout: cilog.LayoutEpoch}} {
+		r := cilog.Record{Module: "module", Time: tm, Level: cilog.INFO, Package: "p", File: "a.go", Line: 1, Message: "abc"}
+		buf = cilog.CSVEncoder{Time: f}.Encode(buf, &r)
+	}
+	r := cilog.NewReader(strings.NewReader(string(buf)))
+	n := 0
+	for r.Next() {
+		assert.True(t, tm.Equal(r.Record().Time))
+		assert.Equal(t, "abc", r.Record().Message)
+		n++
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestConfig_TimeFormat(t *testing.T) {
+	c := cilog.Config{Writer: "stdout", Module: "module", TimeFormat: "iso8601", TimePrecision: "ms", TimeZone: "UTC"}
+	assert.NoError(t, c.Validate())
+
+	w := &stringWriter{}
+	logger, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetWriter(w)
+	logger.Log(1, cilog.INFO, "abc", time.Date(2009, 11, 23, 6, 21, 30, 123456789, time.UTC))
+	assert.True(t, strings.HasPrefix(w.writed, "module,,2009-11-23T06:21:30.123Z,Information,"), w.writed)
+
+	c.TimeFormat = "rfc822"
+	assert.EqualError(t, c.Validate(), "cilog config: time format [rfc822] is not supported, use default, iso8601 or epoch")
+	c.TimeFormat = ""
+	c.TimePrecision = "s"
+	assert.EqualError(t, c.Validate(), "cilog config: time precision [s] is not supported, use ms, us or ns")
+	c.TimePrecision = ""
+	c.TimeZone = "Mars/Olympus"
+	assert.Error(t, c.Validate())
+}
@@ -0,0 +1,210 @@
+package cilog
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// pkgLevel : minimum level for packages or files matching pattern.
+// a pattern ending with ".go" is matched against the file base name, otherwise against the package.
+type pkgLevel struct {
+	pattern string
+	file    bool
+	level   Level
+}
+
+func newPkgLevel(pattern string, lvl Level) pkgLevel {
+	return pkgLevel{pattern: pattern, file: strings.HasSuffix(pattern, ".go"), level: lvl}
+}
+
+// parsePackageLevels : parses "cache=debug,filecache=warning,server*.go=info"
+func parsePackageLevels(spec string) ([]pkgLevel, error) {
+	var levels []pkgLevel
+	for _, kv := range strings.Split(spec, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid package level [%s], expected pattern=level", kv)
+		}
+		pattern := strings.TrimSpace(kv[:i])
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid package pattern [%s], %v", pattern, err)
+		}
+		lvl, err := LevelFromString(strings.TrimSpace(kv[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, newPkgLevel(pattern, lvl))
+	}
+	return levels, nil
+}
+
+// lowestLevel : the lowest level any package can log with
+func lowestLevel(minLevel Level, pkgLevels []pkgLevel) Level {
+	lowest := minLevel
+	for _, p := range pkgLevels {
+		if p.level.Rank() < lowest.Rank() {
+			lowest = p.level
+		}
+	}
+	return lowest
+}
+
+// packageMinLevel : file patterns take precedence over package patterns, the first match wins
+func packageMinLevel(pkgLevels []pkgLevel, pkg string, file string, minLevel Level) Level {
+	for _, p := range pkgLevels {
+		if p.file {
+			if ok, _ := filepath.Match(p.pattern, file); ok {
+				return p.level
+			}
+		}
+	}
+	for _, p := range pkgLevels {
+		if !p.file {
+			if ok, _ := filepath.Match(p.pattern, pkg); ok {
+				return p.level
+			}
+		}
+	}
+	return minLevel
+}
+
+// callerInfo : the caller of a record
+type callerInfo struct {
+	frame Frame
+	// pkg : PackageBase of the function
+	pkg string
+	// file : the base name of the file
+	file string
+}
+
+// unknownCaller : the caller of a record if the stack is not available
+var unknownCaller = &callerInfo{pkg: "???", file: "???"}
+
+var callerCache sync.Map
+
+// callerPC : the pc of the caller of skip frames, 0 is the caller of callerPC. 0 if the stack is not available.
+// runtime.Caller allocates, so the caller is taken with runtime.Callers and the frame is cached by pc
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// callerAt : the caller at pc of callerPC
+func callerAt(pc uintptr) *callerInfo {
+	if pc == 0 {
+		return unknownCaller
+	}
+	if v, ok := callerCache.Load(pc); ok {
+		return v.(*callerInfo)
+	}
+	f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if f.Function == "" {
+		return unknownCaller
+	}
+	c := &callerInfo{
+		frame: Frame{Function: f.Function, File: f.File, Line: f.Line},
+		pkg:   PackageBase(f.Function),
+		file:  filepath.Base(f.File),
+	}
+	callerCache.Store(pc, c)
+	return c
+}
+
+// pcLevel : the package level of the caller at a pc for the generation of the package levels, 0 if no pattern matches
+type pcLevel struct {
+	gen   uint64
+	level Level
+}
+
+// packageLevelAt : the package level of the caller at pc, 0 if no pattern matches.
+// the level is cached by pc, so that the patterns are matched once for each caller while the package levels are not changed
+func (l *Logger) packageLevelAt(pc uintptr, pkgLevels []pkgLevel, gen uint64) Level {
+	if v, ok := l.pcLevels.Load(pc); ok {
+		if e := v.(pcLevel); e.gen == gen {
+			return e.level
+		}
+	}
+	c := callerAt(pc)
+	lvl := packageMinLevel(pkgLevels, c.pkg, c.file, 0)
+	l.pcLevels.Store(pc, pcLevel{gen: gen, level: lvl})
+	return lvl
+}
+
+// setPackageLevels : l.mu must be held, the cached levels of the callers are of the old generation
+func (l *Logger) setPackageLevels(levels []pkgLevel) {
+	l.pkgLevels = levels
+	l.lowestLevel = lowestLevel(l.minLevel, levels)
+	l.pkgGen++
+}
+
+// SetPackageLevels : replaces the per package minimum levels with spec,
+// e.g. "cache=debug,filecache=warning,server*.go=info". an empty spec removes all of them.
+func (l *Logger) SetPackageLevels(spec string) error {
+	levels, err := parsePackageLevels(spec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setPackageLevels(levels)
+	return nil
+}
+
+// SetPackageLevel : sets the minimum level of packages (or files, if pattern ends with ".go") matching pattern
+func (l *Logger) SetPackageLevel(pattern string, lvl Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	levels := make([]pkgLevel, 0, len(l.pkgLevels)+1)
+	for _, p := range l.pkgLevels {
+		if p.pattern != pattern {
+			levels = append(levels, p)
+		}
+	}
+	levels = append(levels, newPkgLevel(pattern, lvl))
+	l.setPackageLevels(levels)
+}
+
+// GetPackageLevels : per package minimum levels in the format of SetPackageLevels
+func (l *Logger) GetPackageLevels() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	specs := make([]string, len(l.pkgLevels))
+	for i, p := range l.pkgLevels {
+		specs[i] = p.pattern + "=" + p.level.String()
+	}
+	return strings.Join(specs, ",")
+}
+
+// RemovePackageLevel : removes the minimum level set with pattern
+func (l *Logger) RemovePackageLevel(pattern string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	levels := make([]pkgLevel, 0, len(l.pkgLevels))
+	for _, p := range l.pkgLevels {
+		if p.pattern != pattern {
+			levels = append(levels, p)
+		}
+	}
+	l.setPackageLevels(levels)
+}
+
+func (l *Logger) packageLevel(pattern string) (Level, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, p := range l.pkgLevels {
+		if p.pattern == pattern {
+			return p.level, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,88 @@
+package cilog_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_SetPackageLevels(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	if err := logger.SetPackageLevels("cilog_test=debug, cache=warning"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cilog_test=debug,cache=warning", logger.GetPackageLevels())
+
+	logger.Log(1, cilog.DEBUG, "abc", time.Now())
+	assert.Contains(t, w.writed, ",Debug,cilog_test::")
+
+	w.writed = ""
+	logger.SetPackageLevel("cilog_test", cilog.ERROR)
+	assert.Equal(t, "cache=warning,cilog_test=error", logger.GetPackageLevels())
+	logger.Log(1, cilog.WARNING, "abc", time.Now())
+	assert.Equal(t, "", w.writed)
+	logger.Log(1, cilog.ERROR, "abc", time.Now())
+	assert.Contains(t, w.writed, ",Error,cilog_test::")
+}
+
+func TestLogger_SetPackageLevels_File(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.DEBUG)
+	if err := logger.SetPackageLevels("cilog_test=debug,vmodule_*.go=warning"); err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	assert.Equal(t, "", w.writed)
+	logger.Log(1, cilog.WARNING, "abc", time.Now())
+	assert.True(t, strings.Contains(w.writed, "::vmodule_test.go:"), w.writed)
+}
+
+func TestLogger_SetPackageLevels_Invalid(t *testing.T) {
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.DEBUG)
+	for _, spec := range []string{"cache", "=debug", "cache=unknown", "[=debug"} {
+		if err := logger.SetPackageLevels(spec); err == nil {
+			t.Errorf("SetPackageLevels(%s) expected error", spec)
+		}
+	}
+	if err := logger.SetPackageLevels(""); err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, "", logger.GetPackageLevels())
+}
+
+func TestLogger_SetPackageLevels_SameCaller(t *testing.T) {
+	w := &stringWriter{}
+	logger := cilog.New(w, "module", "1.0", cilog.INFO)
+	specs := []string{"cilog_test=debug", "cilog_test=warning", "cache=debug", "vmodule_test.go=debug", ""}
+	expected := []bool{true, false, false, true, false}
+	for i, spec := range specs {
+		assert.NoError(t, logger.SetPackageLevels(spec))
+		w.writed = ""
+		// the level of the same caller follows the package levels
+		logger.Log(1, cilog.DEBUG, "abc", time.Now())
+		assert.Equal(t, expected[i], w.writed != "", spec)
+		assert.Equal(t, expected[i], logger.Enabled(cilog.DEBUG), spec)
+	}
+}
+
+func BenchmarkLogger_PackageLevels_Filtered(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.INFO)
+	logger.SetPackageLevels("cache=warning,filecache=error")
+	for n := 0; n < b.N; n++ {
+		logger.Log(1, cilog.DEBUG, "this is log.", time.Now())
+	}
+}
+
+func BenchmarkLogger_PackageLevels_Override(b *testing.B) {
+	logger := cilog.New(dummyWriter{}, "module", "1.0", cilog.INFO)
+	logger.SetPackageLevels("cache=debug,filecache=debug,server*.go=debug")
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		logger.Log(1, cilog.DEBUG, "this is log.", time.Time{})
+	}
+}
@@ -0,0 +1,153 @@
+package cilog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ConfigWatcher : applies a config file to a Logger again whenever the file changes or SIGHUP is received
+type ConfigWatcher struct {
+	path    string
+	logger  *Logger
+	onError func(error)
+
+	mu     sync.Mutex
+	config Config
+	data   []byte
+	output *output
+
+	once sync.Once
+	sigs chan os.Signal
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchConfig : applies the config file at path to l, and checks the file every interval.
+// if interval is not positive, the file is applied again only on SIGHUP or Reload.
+// if a new config is invalid, the error is passed to onError (may be nil) and the current config keeps running.
+// writers are replaced only when their config changes, the old writers are stopped after
+// the logs being written to them are done.
+func WatchConfig(path string, l *Logger, interval time.Duration, onError func(error)) (*ConfigWatcher, error) {
+	cw := &ConfigWatcher{
+		path:    path,
+		logger:  l,
+		onError: onError,
+		sigs:    make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cw.apply(data); err != nil {
+		return nil, err
+	}
+	signal.Notify(cw.sigs, syscall.SIGHUP)
+	go cw.run(interval)
+	return cw, nil
+}
+
+// Config : the config currently applied
+func (cw *ConfigWatcher) Config() Config {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.config
+}
+
+// Reload : reads and applies the config file even if it is not changed
+func (cw *ConfigWatcher) Reload() error {
+	data, err := ioutil.ReadFile(cw.path)
+	if err != nil {
+		return err
+	}
+	return cw.apply(data)
+}
+
+// Close : stops watching, the writers of the logger are not closed
+func (cw *ConfigWatcher) Close() {
+	cw.once.Do(func() {
+		signal.Stop(cw.sigs)
+		close(cw.stop)
+		<-cw.done
+	})
+}
+
+func (cw *ConfigWatcher) run(interval time.Duration) {
+	defer close(cw.done)
+	// a nil channel never receives, SIGHUP only
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		var err error
+		select {
+		case <-cw.stop:
+			return
+		case <-cw.sigs:
+			err = cw.Reload()
+		case <-tick:
+			err = cw.reloadIfChanged()
+		}
+		if err != nil && cw.onError != nil {
+			cw.onError(err)
+		}
+	}
+}
+
+func (cw *ConfigWatcher) reloadIfChanged() error {
+	data, err := ioutil.ReadFile(cw.path)
+	if err != nil {
+		return err
+	}
+	cw.mu.Lock()
+	changed := !bytes.Equal(data, cw.data)
+	cw.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	return cw.apply(data)
+}
+
+func (cw *ConfigWatcher) apply(data []byte) error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	format := "yaml"
+	if isJSONPath(cw.path) {
+		format = "json"
+	}
+	c, err := ParseConfig(data, format)
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	// writers are replaced only if their config is changed
+	o, old := cw.output, (*output)(nil)
+	if o == nil || !reflect.DeepEqual(c.sinks(), cw.config.sinks()) ||
+		c.TimeFormat != cw.config.TimeFormat || c.TimePrecision != cw.config.TimePrecision || c.TimeZone != cw.config.TimeZone {
+		o, old = &output{}, cw.output
+		*o = c.buildOutput()
+	}
+
+	// apply waits for the logs being written to the old writers
+	cw.logger.apply(c, *o)
+	if old != nil {
+		old.close()
+	}
+	cw.config = c
+	cw.data = data
+	cw.output = o
+	return nil
+}
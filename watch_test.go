@@ -0,0 +1,167 @@
+package cilog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/castisdev/cilog"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func countLines(t *testing.T, dir string) int {
+	n := 0
+	filepath.Walk(dir, func(p string, f os.FileInfo, err error) error {
+		if err == nil && f.Mode().IsRegular() && strings.HasSuffix(p, "_example.log") {
+			b, _ := ioutil.ReadFile(p)
+			n += strings.Count(string(b), "\n")
+		}
+		return nil
+	})
+	return n
+}
+
+func TestWatchConfig(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "cilog.yml")
+	dir1 := filepath.Join(dir, "1")
+	dir2 := filepath.Join(dir, "2")
+	writeConfig := func(data string) {
+		tmp := configPath + ".tmp"
+		ioutil.WriteFile(tmp, []byte(data), 0644)
+		os.Rename(tmp, configPath)
+	}
+	writeConfig("dir: " + dir1 + "\nmodule: example\nbufferSize: 16\nminLevel: info\n")
+
+	errs := make(chan error, 10)
+	logger := cilog.New(os.Stderr, "", "", cilog.DEBUG)
+	cw, err := cilog.WatchConfig(configPath, logger, 10*time.Millisecond, func(err error) { errs <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cw.Close()
+	assert.Equal(t, "example", logger.GetModule())
+	assert.Equal(t, cilog.INFO, logger.GetMinLevel())
+
+	const count = 2000
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < count; i++ {
+			logger.Log(1, cilog.INFO, "abc", time.Now())
+			if i%100 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		close(done)
+	}()
+
+	// level only, the writer is kept
+	w := logger.GetWriter()
+	writeConfig("dir: " + dir1 + "\nmodule: example\nbufferSize: 16\nminLevel: warning\n")
+	assert.True(t, waitFor(func() bool { return logger.GetMinLevel() == cilog.WARNING }))
+	assert.True(t, w == logger.GetWriter())
+
+	// invalid config is rejected
+	writeConfig("dir: " + dir2 + "\nmodule: example\nminLevel: unknown\n")
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Error("invalid config is not reported")
+	}
+	assert.Equal(t, cilog.WARNING, logger.GetMinLevel())
+
+	writeConfig("dir: " + dir2 + "\nmodule: example\nbufferSize: 16\nminLevel: info\n")
+	assert.True(t, waitFor(func() bool { return cw.Config().Dir == dir2 }))
+	assert.True(t, w != logger.GetWriter())
+
+	<-done
+	logger.Log(1, cilog.INFO, "abc", time.Now())
+	logger.Close()
+	logged := countLines(t, dir1) + countLines(t, dir2)
+	assert.True(t, logged <= count+1 && logged > 0, "logged %d", logged)
+	assert.NotEqual(t, 0, countLines(t, dir2))
+}
+
+func TestWatchConfig_NoDrop(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "cilog.json")
+	ioutil.WriteFile(configPath, []byte(`{"dir": "`+filepath.Join(dir, "1")+`", "module": "example", "bufferSize": 4}`), 0644)
+
+	logger := cilog.New(os.Stderr, "", "", cilog.DEBUG)
+	cw, err := cilog.WatchConfig(configPath, logger, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cw.Close()
+
+	const count = 1000
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < count; i++ {
+			logger.Log(1, cilog.INFO, "abc", time.Now())
+		}
+		close(done)
+	}()
+	for i := 2; i < 10; i++ {
+		ioutil.WriteFile(configPath, []byte(`{"dir": "`+filepath.Join(dir, "n")+`", "module": "example", "bufferSize": `+
+			string(rune('0'+i))+`}`), 0644)
+		if err := cw.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	logger.Close()
+	assert.Equal(t, count, countLines(t, dir))
+}
+
+func TestWatchConfig_SignalOnly(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "cilog.yml")
+	config := "dir: " + filepath.Join(dir, "logs") + "\nmodule: example\nminLevel: "
+	ioutil.WriteFile(configPath, []byte(config+"info\n"), 0644)
+	logger := cilog.New(&stringWriter{}, "module", "1.0", cilog.DEBUG)
+	defer logger.Close()
+	cw, err := cilog.WatchConfig(configPath, logger, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cw.Close()
+	assert.Equal(t, cilog.INFO, logger.GetMinLevel())
+
+	ioutil.WriteFile(configPath, []byte(config+"warning\n"), 0644)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, cilog.INFO, logger.GetMinLevel())
+
+	p, _ := os.FindProcess(os.Getpid())
+	p.Signal(syscall.SIGHUP)
+	assert.True(t, waitFor(func() bool { return logger.GetMinLevel() == cilog.WARNING }))
+}
@@ -1,30 +1,64 @@
 package cilog
 
 import (
+	"bytes"
+	"errors"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
 type logMsg struct {
-	output []byte
-	t      time.Time
+	output  []byte
+	t       time.Time
+	lvl     Level
+	flushed chan struct{}
 }
 
 // LogWriter :
 type LogWriter struct {
-	lock       sync.Mutex
-	dir        string
-	module     string
-	rotateSize int64
-	curYearDay int
-	fp         *os.File
-	fpath      string
-	queue      chan logMsg
+	lock         sync.Mutex
+	dir          string
+	module       string
+	rotateSize   int64
+	curDay       time.Time
+	fp           *os.File
+	fpath        string
+	size         int64
+	copyTruncate bool
+	processLock  bool
+	lockFp       *os.File
+	noLink       bool
+	linkRelative bool
+	linkDir      string
+	linkName     string
+	errorHandler func(error)
+	fileStart    time.Time
+	fileEnd      time.Time
+	fileBytes    int64
+	fileLines    int64
+	closeHooks   []func(ClosedFile)
+	closeCmd     []string
+	header       bool
+	moduleVer    string
+	prevPath     string
+	totalBytes   int64
+	retention    int
+	levelFiles   []levelFile
+	clock        Clock
+	writeErrors  int64
+	hookWG       sync.WaitGroup
+	// queueMu : guards queue, the queue is closed and set to nil by Stop while no one sends to it
+	queueMu sync.RWMutex
+	queue   chan logMsg
+	done    chan struct{}
 }
 
 // NewLogWriter :
@@ -33,9 +67,13 @@ func NewLogWriter(dir string, module string, rotateSize int64) *LogWriter {
 }
 
 func logIndex(fname string) int {
-	start := strings.Index(fname, "[")
+	// the index follows the date, the module may have brackets too, e.g. "2014-08-12[1]_example[2].log"
+	start := len("2006-01-02")
+	if len(fname) <= start || fname[start] != '[' {
+		return 0
+	}
 	end := strings.Index(fname, "]")
-	if start == -1 || end == -1 {
+	if end == -1 {
 		return 0
 	}
 	s := fname[start+1 : end]
@@ -48,7 +86,7 @@ func LogPath(dir string, module string, maxFileSize int64, now time.Time) string
 	pre := now.Format("2006-01-02")
 	monthDir := filepath.Join(dir, now.Format("2006-01"))
 	// e.g. "2014-08-12[1]_example.log" or "2014-08-12_example.log"
-	pattern := pre + "(\\[[0-9]+\\])?" + "_" + module + ".log"
+	pattern := "^" + pre + "(\\[[0-9]+\\])?" + "_" + regexp.QuoteMeta(module) + "\\.log$"
 	regx, _ := regexp.Compile(pattern)
 	idx := 0
 	filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
@@ -75,67 +113,448 @@ func LogPath(dir string, module string, maxFileSize int64, now time.Time) string
 	return filepath.Join(monthDir, log)
 }
 
+// startOfDay : 00:00 of the day of t in the location of t
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
 // WriteWithTime :
 func (w *LogWriter) WriteWithTime(output []byte, t time.Time) (int, error) {
-	if w.queue == nil {
-		w.lock.Lock()
-		defer w.lock.Unlock()
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	n, err := w.write(output, t)
+	if err != nil {
+		w.writeErrors++
 	}
-	if w.curYearDay != t.YearDay() {
-		w.closeFile()
-		w.curYearDay = t.YearDay()
+	return n, err
+}
+
+// write : w.lock must be held
+func (w *LogWriter) write(output []byte, t time.Time) (int, error) {
+	if w.processLock {
+		if err := w.lockProcess(); err != nil {
+			return 0, err
+		}
+		defer funlock(w.lockFp)
+	}
+
+	// the day only moves forward, a record dated before the current day (e.g. logged before midnight
+	// by a goroutine finishing late) is written to the current file, the file of its day is finished
+	fileTime := t
+	if day := startOfDay(t); day.After(w.curDay) {
+		w.closeFile(CloseByDay)
+		w.curDay = day
+	} else if day.Before(w.curDay) {
+		fileTime = w.curDay
 	}
 
 	if _, err := os.Stat(w.fpath); os.IsNotExist(err) {
-		w.closeFile()
+		w.closeFile(closeByRemove)
+	}
+
+	if w.fp != nil && w.processLock {
+		// other processes append to the same file, so the size must be read from the file
+		if fi, err := w.fp.Stat(); err == nil {
+			w.size = fi.Size()
+		}
+		if w.size > w.rotateSize {
+			w.closeFile(CloseBySize)
+		}
 	}
 
 	if w.fp == nil {
-		p := LogPath(w.dir, w.module, w.rotateSize, t)
-		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		if err := w.openFile(fileTime); err != nil {
 			return 0, err
 		}
-		f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return 0, err
+	} else if w.copyTruncate {
+		// the file has been truncated by someone else (e.g. logrotate copytruncate)
+		if fi, err := w.fp.Stat(); err == nil && fi.Size() < w.size {
+			w.size = fi.Size()
 		}
-		w.fp = f
-		w.fpath = p
+	}
 
-		abspath, _ := filepath.Abs(p)
-		symfilepath := filepath.Join(filepath.Dir(filepath.Dir(abspath)), w.module+".log")
-		if _, err := os.Lstat(symfilepath); err == nil {
-			os.Remove(symfilepath)
-		}
-		os.Symlink(abspath, symfilepath)
+	n, err := w.writeFile(output, t)
+	if err != nil {
+		return n, err
+	}
+	if w.size > w.rotateSize {
+		w.closeFile(CloseBySize)
 	}
+	return n, nil
+}
 
+func (w *LogWriter) writeFile(output []byte, t time.Time) (int, error) {
 	n, err := w.fp.Write(output)
+	w.size += int64(n)
+	w.totalBytes += int64(n)
+	if w.fileStart.IsZero() {
+		w.fileStart = t
+	}
+	if t.After(w.fileEnd) {
+		w.fileEnd = t
+	}
+	w.fileBytes += int64(n)
+	w.fileLines += int64(bytes.Count(output[:n], []byte{'\n'}))
+	return n, err
+}
+
+func (w *LogWriter) openFile(t time.Time) error {
+	return w.openFileAt(LogPath(w.dir, w.module, w.rotateSize, t), t)
+}
 
-	size, err := w.fp.Seek(0, os.SEEK_CUR)
+func (w *LogWriter) openFileAt(p string, t time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	if size > w.rotateSize {
-		w.closeFile()
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
 	}
-	return n, err
+	w.fp = f
+	w.fpath = p
+	w.size = fi.Size()
+
+	if !w.noLink {
+		if err := w.updateLink(p); err != nil {
+			w.reportError(err)
+		}
+	}
+	if w.retention > 0 {
+		w.removeOldLogs(t)
+	}
+	if w.header && w.size == 0 {
+		if _, err := w.writeFile(w.headerRecord(t), t); err != nil {
+			w.reportError(err)
+		}
+	}
+	return nil
+}
+
+// linkSeq : makes the temporary links of updateLink unique in the process
+var linkSeq uint64
+
+// updateLink : points the "current" link to p, the link is replaced atomically by renaming a temporary link
+func (w *LogWriter) updateLink(p string) error {
+	abspath, err := filepath.Abs(p)
+	if err != nil {
+		return err
+	}
+	linkDir := filepath.Dir(filepath.Dir(abspath))
+	if w.linkDir != "" {
+		if linkDir, err = filepath.Abs(w.linkDir); err != nil {
+			return err
+		}
+	}
+	linkName := w.module + ".log"
+	if w.linkName != "" {
+		linkName = w.linkName
+	}
+	target := abspath
+	if w.linkRelative {
+		if target, err = filepath.Rel(linkDir, abspath); err != nil {
+			return err
+		}
+	}
+
+	linkPath := filepath.Join(linkDir, linkName)
+	// writers of a process may share the link, e.g. the files of a LevelFileWriter
+	tmpPath := linkPath + ".tmp" + strconv.Itoa(os.Getpid()) + "." + strconv.FormatUint(atomic.AddUint64(&linkSeq, 1), 10)
+	os.Remove(tmpPath)
+	if err := os.Symlink(target, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, linkPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// SetLinkEnabled : enables or disables the link to the current log file (enabled by default)
+func (w *LogWriter) SetLinkEnabled(enabled bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.noLink = !enabled
+}
+
+// SetLinkRelative : if enabled, the link points to the current log file with a relative path
+func (w *LogWriter) SetLinkRelative(relative bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.linkRelative = relative
+}
+
+// SetLinkPath : sets the directory and the name of the link to the current log file.
+// empty dir means the log dir, empty name means "module.log"
+func (w *LogWriter) SetLinkPath(dir string, name string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.linkDir = dir
+	w.linkName = name
+}
+
+// SetErrorHandler : sets the function called with errors that can not be returned to the caller,
+// e.g. link update failures or async write failures. it must not write to the LogWriter.
+func (w *LogWriter) SetErrorHandler(f func(error)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.errorHandler = f
+}
+
+// reportError : w.lock must be held
+func (w *LogWriter) reportError(err error) {
+	if w.errorHandler != nil {
+		w.errorHandler(err)
+	}
+}
+
+func (w *LogWriter) handleError(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.reportError(err)
 }
 
-// Write :
+// SetCopyTruncate : if enabled, the writer detects that the current file has been truncated
+// (logrotate copytruncate) and resets the size used for rotateSize.
+func (w *LogWriter) SetCopyTruncate(enabled bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.copyTruncate = enabled
+}
+
+// ErrProcessLockNotSupported : returned by SetProcessLock on the platforms other than linux
+var ErrProcessLockNotSupported = errors.New("cilog: process lock is not supported on this platform")
+
+// SetProcessLock : if enabled, every write holds an advisory lock (flock) on a lock file in dir,
+// so that several processes can share the rotation of the same dir and module.
+// (linux only, ErrProcessLockNotSupported is returned and the setting is not changed on the others)
+func (w *LogWriter) SetProcessLock(enabled bool) error {
+	if enabled && !flockSupported {
+		return ErrProcessLockNotSupported
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.processLock = enabled
+	return nil
+}
+
+func (w *LogWriter) lockProcess() error {
+	lockPath := filepath.Join(w.dir, "."+w.module+".lock")
+	if w.lockFp != nil {
+		// the lock file may have been removed with dir
+		if _, err := os.Stat(lockPath); os.IsNotExist(err) {
+			w.lockFp.Close()
+			w.lockFp = nil
+		}
+	}
+	if w.lockFp == nil {
+		if err := os.MkdirAll(w.dir, 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return err
+		}
+		w.lockFp = f
+	}
+	return flock(w.lockFp)
+}
+
+// Reopen : closes the current file and opens it again, the path is recomputed with LogPath.
+func (w *LogWriter) Reopen() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.processLock {
+		if err := w.lockProcess(); err != nil {
+			return err
+		}
+		defer funlock(w.lockFp)
+	}
+	w.closeFile(CloseByReopen)
+	now := w.now()
+	w.curDay = startOfDay(now)
+	if err := w.openFile(now); err != nil {
+		return err
+	}
+	for _, lf := range w.levelFiles {
+		if err := lf.w.Reopen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rotate : closes the current file and opens a new file with the next index
+func (w *LogWriter) Rotate() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.processLock {
+		if err := w.lockProcess(); err != nil {
+			return err
+		}
+		defer funlock(w.lockFp)
+	}
+	w.closeFile(CloseByRotate)
+	now := w.now()
+	w.curDay = startOfDay(now)
+	// every existing file is bigger than -1, so LogPath gives the next index
+	if err := w.openFileAt(LogPath(w.dir, w.module, -1, now), now); err != nil {
+		return err
+	}
+	for _, lf := range w.levelFiles {
+		if err := lf.w.Rotate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush : waits until the queued logs are written and syncs the current file to the disk
+func (w *LogWriter) Flush() error {
+	w.queueMu.RLock()
+	if w.queue != nil {
+		flushed := make(chan struct{})
+		w.queue <- logMsg{flushed: flushed}
+		w.queueMu.RUnlock()
+		<-flushed
+	} else {
+		w.queueMu.RUnlock()
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, lf := range w.levelFiles {
+		if err := lf.w.Flush(); err != nil {
+			return err
+		}
+	}
+	if w.fp == nil {
+		return nil
+	}
+	return w.fp.Sync()
+}
+
+// WriterStats :
+type WriterStats struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Bytes    int64  `json:"bytes"`
+	QueueLen int    `json:"queueLen"`
+	QueueCap int    `json:"queueCap"`
+	Errors   int64  `json:"errors"`
+}
+
+// Stats : Bytes is the total bytes written, Errors is the number of writes failed.
+// the queue of LogWriter blocks when it is full, so no records are dropped
+func (w *LogWriter) Stats() WriterStats {
+	w.queueMu.RLock()
+	queueLen, queueCap := len(w.queue), cap(w.queue)
+	w.queueMu.RUnlock()
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return WriterStats{
+		Path:     w.fpath,
+		Size:     w.size,
+		Bytes:    w.totalBytes,
+		QueueLen: queueLen,
+		QueueCap: queueCap,
+		Errors:   w.writeErrors,
+	}
+}
+
+// ReopenOnSignal : calls Reopen whenever one of sigs (SIGHUP if empty) is received.
+// the returned function stops the handler.
+func (w *LogWriter) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := w.Reopen(); err != nil {
+					w.handleError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// Write : writes synchronously if the writer is not started or is stopped
 func (w *LogWriter) Write(output []byte) (int, error) {
+	w.queueMu.RLock()
+	defer w.queueMu.RUnlock()
 	if w.queue == nil {
-		return w.WriteWithTime(output, time.Now())
+		return w.WriteWithTime(output, w.now())
 	}
 
-	w.queue <- logMsg{output, time.Now()}
+	// output is copied, the buffer of the caller may be reused after Write returns
+	w.queue <- logMsg{output: append([]byte(nil), output...), t: w.now()}
 	return len(output), nil
 }
 
-func (w *LogWriter) closeFile() {
+func (w *LogWriter) closeFile(reason CloseReason) {
+	if w.fp == nil {
+		return
+	}
+	if w.header && reason != closeByRemove {
+		if _, err := w.writeFile(w.footerRecord(w.fileEnd, reason), w.fileEnd); err != nil {
+			w.reportError(err)
+		}
+	}
 	w.fp.Close()
+	if reason != closeByRemove {
+		w.prevPath = w.fpath
+		w.notifyClose(ClosedFile{
+			Path:   w.fpath,
+			Start:  w.fileStart,
+			End:    w.fileEnd,
+			Bytes:  w.fileBytes,
+			Lines:  w.fileLines,
+			Reason: reason,
+		})
+	}
 	w.fp = nil
 	w.fpath = ""
+	w.size = 0
+	w.fileStart = time.Time{}
+	w.fileEnd = time.Time{}
+	w.fileBytes = 0
+	w.fileLines = 0
+}
+
+// Close : closes the current file and waits for the close hooks to finish
+func (w *LogWriter) Close() error {
+	w.lock.Lock()
+	w.closeFile(CloseByShutdown)
+	if w.lockFp != nil {
+		w.lockFp.Close()
+		w.lockFp = nil
+	}
+	levelFiles := w.levelFiles
+	w.lock.Unlock()
+	for _, lf := range levelFiles {
+		lf.w.Close()
+	}
+	w.hookWG.Wait()
+	return nil
 }
 
 // Start :
@@ -145,23 +564,52 @@ func (w *LogWriter) Start() {
 
 // StartWithBufferSize :
 func (w *LogWriter) StartWithBufferSize(size int) {
+	w.queueMu.Lock()
+	defer w.queueMu.Unlock()
+	if w.queue != nil {
+		return
+	}
 	w.queue = make(chan logMsg, size)
-	go w.serve()
+	w.done = make(chan struct{})
+	go w.serve(w.queue, w.done)
+}
+
+// started : whether the writer writes asynchronously
+func (w *LogWriter) started() bool {
+	w.queueMu.RLock()
+	defer w.queueMu.RUnlock()
+	return w.queue != nil
 }
 
-// Stop :
+// Stop : writes the queued logs and closes the writer. the writer writes synchronously after Stop,
+// and Stop of the stopped writer only closes the writer
 func (w *LogWriter) Stop() {
-	close(w.queue)
+	w.queueMu.Lock()
+	queue, done := w.queue, w.done
+	w.queue, w.done = nil, nil
+	w.queueMu.Unlock()
+	if queue != nil {
+		close(queue)
+		<-done
+	}
+	w.Close()
 }
 
-func (w *LogWriter) serve() {
+func (w *LogWriter) serve(queue chan logMsg, done chan struct{}) {
 	for {
 		select {
-		case msg, ok := <-w.queue:
+		case msg, ok := <-queue:
 			if !ok {
+				close(done)
 				return
 			}
-			w.WriteWithTime(msg.output, msg.t)
+			if msg.flushed != nil {
+				close(msg.flushed)
+				continue
+			}
+			if _, err := w.writeLevel(msg.output, msg.lvl, msg.t); err != nil {
+				w.handleError(err)
+			}
 		}
 	}
 }
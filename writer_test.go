@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -220,6 +221,304 @@ func TestLogWriter_Write_DeleteFile_Write(t *testing.T) {
 	}
 }
 
+func TestLogWriter_Reopen(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	fpath := filepath.Join(dir, now.Format("2006-01"), now.Format("2006-01-02")+"_module.log")
+
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.Write([]byte("abc"))
+	os.Rename(fpath, fpath+".1")
+	if err := w.Reopen(); err != nil {
+		t.Error(err)
+	}
+	w.Write([]byte("def"))
+
+	b1, err := ioutil.ReadFile(fpath + ".1")
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b1) != "abc" {
+		t.Errorf("log expected abc, but %s", string(b1))
+	}
+	b2, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b2) != "def" {
+		t.Errorf("log expected def, but %s", string(b2))
+	}
+}
+
+func TestLogWriter_ReopenOnSignal(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	fpath := filepath.Join(dir, now.Format("2006-01"), now.Format("2006-01-02")+"_module.log")
+
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	stop := w.ReopenOnSignal(syscall.SIGUSR1)
+	defer stop()
+	w.Write([]byte("abc"))
+	os.Rename(fpath, fpath+".1")
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(fpath); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("log file %s is not reopened", fpath)
+}
+
+func TestLogWriter_CopyTruncate(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	fpath := filepath.Join(dir, "2009-11", "2009-11-23_module.log")
+
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.SetCopyTruncate(true)
+	w.WriteWithTime([]byte("abcd"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	os.Truncate(fpath, 0)
+	w.WriteWithTime([]byte("ef"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("gh"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+
+	b, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != "efgh" {
+		t.Errorf("log expected efgh, but %s", string(b))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2009-11", "2009-11-23[1]_module.log")); err == nil {
+		t.Errorf("log should not be rotated")
+	}
+}
+
+func TestLogWriter_ProcessLock(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	const rotateSize = 10
+	const line = "abcdef\n"
+	const count = 100
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		// each writer acts as a separate process sharing dir and module
+		w := cilog.NewLogWriter(dir, "module", rotateSize)
+		if err := w.SetProcessLock(true); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			for n := 0; n < count; n++ {
+				w.WriteWithTime([]byte(line), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "2009-11"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size()
+		if f.Size() > rotateSize+int64(len(line)) {
+			t.Errorf("%s size %d is bigger than rotateSize", f.Name(), f.Size())
+		}
+	}
+	if total != int64(4*count*len(line)) {
+		t.Errorf("total size expected %d, but %d", 4*count*len(line), total)
+	}
+}
+
+func TestLogWriter_Link(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.WriteWithTime([]byte("abcdef"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+	w.WriteWithTime([]byte("ghi"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+
+	target, err := os.Readlink(filepath.Join(dir, "module.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := filepath.Abs(filepath.Join(dir, "2009-11", "2009-11-23[1]_module.log"))
+	if target != expected {
+		t.Errorf("link expected %s, but %s", expected, target)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "module.log"))
+	if string(b) != "ghi" {
+		t.Errorf("log expected ghi, but %s", string(b))
+	}
+}
+
+func TestLogWriter_LinkRelative(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.SetLinkRelative(true)
+	w.SetLinkPath(filepath.Join(dir, "current"), "current.log")
+	os.MkdirAll(filepath.Join(dir, "current"), 0775)
+	w.WriteWithTime([]byte("abc"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+
+	target, err := os.Readlink(filepath.Join(dir, "current", "current.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := filepath.Join("..", "2009-11", "2009-11-23_module.log")
+	if target != expected {
+		t.Errorf("link expected %s, but %s", expected, target)
+	}
+}
+
+func TestLogWriter_LinkDisabled(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.SetLinkEnabled(false)
+	w.WriteWithTime([]byte("abc"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+
+	if _, err := os.Lstat(filepath.Join(dir, "module.log")); err == nil {
+		t.Errorf("link should not exist")
+	}
+}
+
+func TestLogWriter_LinkError(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	w := cilog.NewLogWriter(dir, "module", 5)
+	w.SetErrorHandler(func(err error) { errs = append(errs, err) })
+	w.SetLinkPath(filepath.Join(dir, "notexists"), "")
+	if _, err := w.WriteWithTime([]byte("abc"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local)); err != nil {
+		t.Error(err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("link error expected, but %v", errs)
+	}
+}
+
+func TestLogWriter_SharedLink(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		w := cilog.NewLogWriter(dir, fmt.Sprintf("module%d", i), 5)
+		w.SetErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		})
+		w.SetLinkPath(dir, "current.log")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every write opens a new file and updates the link
+			for j := 0; j < 50; j++ {
+				w.WriteWithTime([]byte("abcdef"), time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local))
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) != 0 {
+		t.Errorf("no link error expected, but %v", errs)
+	}
+	if _, err := os.Readlink(filepath.Join(dir, "current.log")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLogWriter_AfterStop(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 1024)
+	w.SetClock(&fakeClock{t: time.Date(2009, 11, 23, 0, 0, 0, 0, time.Local)})
+	w.Start()
+	w.Write([]byte("abc\n"))
+	w.Stop()
+
+	// the stopped writer writes synchronously
+	if _, err := w.Write([]byte("def\n")); err != nil {
+		t.Error(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Error(err)
+	}
+	w.Stop()
+	w.Stop()
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "2009-11", "2009-11-23_module.log"))
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != "abc\ndef\n" {
+		t.Errorf("log expected abc def, but %s", string(b))
+	}
+}
+
+func TestLogWriter_StopConcurrently(t *testing.T) {
+	idv4, _ := uuid.NewRandom()
+	dir := path.Join("ut.dir", idv4.String())
+	os.MkdirAll(dir, 0775)
+	defer os.RemoveAll(dir)
+
+	w := cilog.NewLogWriter(dir, "module", 1024*1024)
+	w.StartWithBufferSize(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				w.Write([]byte(fmt.Sprintf("this is log. line:%d\n", n)))
+				if n%10 == 0 {
+					w.Flush()
+				}
+			}
+		}()
+	}
+	w.Stop()
+	wg.Wait()
+	w.Stop()
+}
+
 func BenchmarkLogWriter_Write(b *testing.B) {
 	idv4, _ := uuid.NewRandom()
 	dir := path.Join("ut.dir", idv4.String())